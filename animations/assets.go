@@ -0,0 +1,246 @@
+package animations
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"seal-ascii/convert"
+)
+
+const (
+	frameExt     = ".txt"           // extension of embedded frame files
+	colorExt     = ".color"         // optional per-cell colours of a frame
+	manifestName = "animation.json" // optional per-animation settings
+)
+
+// manifest is the optional animation.json stored next to the frame files
+type manifest struct {
+	Sleep     string         `json:"sleep,omitempty"`     // default frame duration, e.g. "70ms"
+	Durations map[int]string `json:"durations,omitempty"` // frame index to duration, for holds
+	Mode      string         `json:"mode,omitempty"`      // default playback mode, e.g. "pingpong"
+	Loops     int            `json:"loops,omitempty"`     // default loop count, 0 loops forever
+	Outro     string         `json:"outro,omitempty"`     // message shown after a finite playback
+	Charset   string         `json:"charset,omitempty"`   // glyphs the frames are drawn with, braille if unset
+	Crop      bool           `json:"crop,omitempty"`      // crop to the union bounding box of the frames
+	Margin    int            `json:"margin,omitempty"`    // blank cells kept around the cropped box
+	Trim      bool           `json:"trim,omitempty"`      // drop trailing blank cells from each line
+}
+
+// assets holds one directory per animation, each containing its frames as
+// text files that sort in playback order
+//
+//go:embed assets
+var assets embed.FS
+
+func init() {
+	loaded, err := Load(assets, "assets")
+	if err != nil {
+		panic(fmt.Sprintf("animations: %v", err))
+	}
+	for name, animation := range loaded {
+		FrameMap[name] = animation
+	}
+}
+
+// Load builds a FrameType for every animation directory under root in fsys
+func Load(fsys fs.FS, root string) (map[string]*FrameType, error) {
+	entries, err := fs.ReadDir(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := make(map[string]*FrameType, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		animation, err := LoadAnimation(fsys, path.Join(root, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		loaded[entry.Name()] = animation
+	}
+	return loaded, nil
+}
+
+// LoadAnimation builds a FrameType from the frames and optional manifest in dir
+func LoadAnimation(fsys fs.FS, dir string) (*FrameType, error) {
+	frames, err := LoadFrames(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	colors, err := LoadColors(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := fs.ReadFile(fsys, path.Join(dir, manifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return DefaultFrameType(frames).WithColors(colors), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", path.Join(dir, manifestName), err)
+	}
+
+	sleep := DefaultSleep
+	if m.Sleep != "" {
+		if sleep, err = time.ParseDuration(m.Sleep); err != nil {
+			return nil, fmt.Errorf("%s: sleep: %w", dir, err)
+		}
+	}
+	holds := make(map[int]time.Duration, len(m.Durations))
+	for index, value := range m.Durations {
+		if index < 0 || index >= len(frames) {
+			return nil, fmt.Errorf("%s: duration for frame %d out of range", dir, index)
+		}
+		if holds[index], err = time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("%s: frame %d: %w", dir, index, err)
+		}
+	}
+	playback, err := Playback{Outro: m.Outro}.Override(m.Mode, "")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+	if m.Loops < 0 {
+		return nil, fmt.Errorf("%s: negative loop count", dir)
+	}
+	if m.Loops > 0 {
+		playback.Loops = m.Loops
+	}
+
+	charset, err := convert.ParseCharset(m.Charset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+
+	animation := NewHeldFrameType(frames, sleep, holds).WithPlayback(playback).WithColors(colors).WithCharset(charset)
+	switch {
+	case m.Crop:
+		animation = animation.Normalize(m.Margin, m.Trim)
+	case m.Trim:
+		animation = animation.TrimTrailing()
+	}
+	return animation, nil
+}
+
+// Assets returns the files LoadAnimation reads the animation back from,
+// keyed by file name: one text file per frame, a colour file for each
+// coloured frame and a manifest with the timing, playback and charset
+func (f *FrameType) Assets() (map[string][]byte, error) {
+	files := make(map[string][]byte, 2*len(f.frames)+1)
+	for i, frame := range f.frames {
+		base := fmt.Sprintf("frame_%04d", i+1)
+		files[base+frameExt] = []byte(frame)
+		if colors := f.GetColors(i); colors != nil {
+			files[base+colorExt] = convert.FormatColors(colors)
+		}
+	}
+
+	// The most common duration becomes the sleep, the rest are holds
+	counts := map[time.Duration]int{}
+	sleep := f.sleep
+	for _, d := range f.timings() {
+		counts[d]++
+		if counts[d] > counts[sleep] {
+			sleep = d
+		}
+	}
+	m := manifest{Sleep: sleep.String(), Loops: f.playback.Loops, Outro: f.playback.Outro}
+	for i, d := range f.timings() {
+		if d != sleep {
+			if m.Durations == nil {
+				m.Durations = map[int]string{}
+			}
+			m.Durations[i] = d.String()
+		}
+	}
+	if f.playback.Mode != Forward {
+		m.Mode = f.playback.Mode.String()
+	}
+	if f.charset != convert.Braille {
+		m.Charset = f.charset.String()
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files[manifestName] = append(data, '\n')
+	return files, nil
+}
+
+// LoadFrames reads the frame files in dir ordered by file name
+func LoadFrames(fsys fs.FS, dir string) ([]string, error) {
+	names, err := frameNames(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make([]string, 0, len(names))
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, string(data))
+	}
+	return frames, nil
+}
+
+// LoadColors reads the colour file stored next to each frame in dir, frames
+// without one are left uncoloured
+func LoadColors(fsys fs.FS, dir string) ([][][]color.RGBA, error) {
+	names, err := frameNames(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	colors := make([][][]color.RGBA, len(names))
+	for i, name := range names {
+		file := path.Join(dir, strings.TrimSuffix(name, frameExt)+colorExt)
+		data, err := fs.ReadFile(fsys, file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if colors[i], err = convert.ParseColors(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return colors, nil
+}
+
+// frameNames lists the frame files in dir sorted into playback order
+func frameNames(fsys fs.FS, dir string) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), frameExt) {
+			names = append(names, entry.Name())
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no frames in %s", dir)
+	}
+	sort.Strings(names)
+	return names, nil
+}
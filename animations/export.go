@@ -0,0 +1,37 @@
+package animations
+
+// Frame is one frame of an animation in the JSON API
+type Frame struct {
+	Text       string `json:"text"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// FrameSet is every frame of an animation, in order, as served to players
+type FrameSet struct {
+	Name    string  `json:"name"`
+	Charset string  `json:"charset"`
+	Width   int     `json:"width"`  // in cells
+	Height  int     `json:"height"` // in cells
+	Mode    string  `json:"mode"`   // default playback mode
+	Loops   int     `json:"loops"`  // default loop count, 0 loops forever
+	Frames  []Frame `json:"frames"`
+}
+
+// Export returns the frames of the animation registered as name with their
+// durations
+func (f *FrameType) Export(name string) FrameSet {
+	width, height := f.Size()
+	set := FrameSet{
+		Name:    name,
+		Charset: f.charset.String(),
+		Width:   width,
+		Height:  height,
+		Mode:    f.playback.Mode.String(),
+		Loops:   f.playback.Loops,
+		Frames:  make([]Frame, len(f.frames)),
+	}
+	for i, frame := range f.frames {
+		set.Frames[i] = Frame{Text: frame, DurationMS: f.GetSleep(i).Milliseconds()}
+	}
+	return set
+}
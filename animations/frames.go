@@ -1,11 +1,28 @@
 package animations
 
-import "time"
+//go:generate go run ../cmd/sealgen -dir ../frames_img -out assets/seal -color
+
+import (
+	"image/color"
+	"sync"
+	"time"
+
+	"seal-ascii/convert"
+)
+
+// DefaultSleep is the frame duration used when an animation doesn't set one
+const DefaultSleep = 70 * time.Millisecond
 
 // FrameType defines the interface for animation frames
 type FrameType struct {
-	frames []string
-	sleep  time.Duration
+	frames      []string
+	sleep       time.Duration
+	durations   []time.Duration  // optional per-frame durations, zero means sleep
+	colors      [][][]color.RGBA // optional per-cell colours of each frame
+	backgrounds [][][]color.RGBA // optional per-cell background colours
+	charset     convert.Charset  // glyphs the frames are drawn with
+	playback    Playback         // default playback, loops forever unless set
+	variants    *sync.Map        // fitted and recoded variants keyed by size bucket or charset
 }
 
 // GetFrame returns the frame at the specified index with bounds checking
@@ -21,28 +38,132 @@ func (f *FrameType) GetLength() int {
 	return len(f.frames)
 }
 
-// GetSleep returns the duration to sleep between frames
-func (f *FrameType) GetSleep() time.Duration {
+// GetSleep returns how long the frame at index stays on screen
+func (f *FrameType) GetSleep(index int) time.Duration {
+	if index >= 0 && index < len(f.durations) && f.durations[index] > 0 {
+		return f.durations[index]
+	}
 	return f.sleep
 }
 
+// GetDuration returns the time one full pass over the frames takes
+func (f *FrameType) GetDuration() time.Duration {
+	var total time.Duration
+	for i := range f.frames {
+		total += f.GetSleep(i)
+	}
+	return total
+}
+
+// GetColors returns the per-cell colours of the frame at index, row by row,
+// or nil if the frame is uncoloured
+func (f *FrameType) GetColors(index int) [][]color.RGBA {
+	if index < 0 || index >= len(f.colors) {
+		return nil
+	}
+	return f.colors[index]
+}
+
+// GetBackgrounds returns the per-cell background colours of the frame at
+// index, or nil if it has none. Cells with a zero alpha keep the terminal's
+// background.
+func (f *FrameType) GetBackgrounds(index int) [][]color.RGBA {
+	if index < 0 || index >= len(f.backgrounds) {
+		return nil
+	}
+	return f.backgrounds[index]
+}
+
+// GetCharset returns the glyphs the frames are drawn with
+func (f *FrameType) GetCharset() convert.Charset {
+	return f.charset
+}
+
+// WithCharset returns a copy of the animation marked as drawn with charset.
+// Only braille animations can be fitted and recoded, so frames drawn any
+// other way are served as they are.
+func (f *FrameType) WithCharset(charset convert.Charset) *FrameType {
+	c := *f
+	c.charset = charset
+	c.variants = new(sync.Map)
+	return &c
+}
+
+// HasColor reports whether any frame carries colours
+func (f *FrameType) HasColor() bool {
+	return len(f.colors) > 0
+}
+
+// WithColors returns a copy of the animation with per-cell colours, one grid
+// per frame. Nil grids leave a frame uncoloured.
+func (f *FrameType) WithColors(colors [][][]color.RGBA) *FrameType {
+	c := *f
+	c.colors = nil
+	for _, grid := range colors {
+		if grid != nil {
+			c.colors = make([][][]color.RGBA, len(f.frames))
+			copy(c.colors, colors)
+			break
+		}
+	}
+	c.variants = new(sync.Map)
+	return &c
+}
+
+// GetPlayback returns the animation's default playback
+func (f *FrameType) GetPlayback() Playback {
+	return f.playback
+}
+
+// WithPlayback returns a copy of the animation with a different default playback
+func (f *FrameType) WithPlayback(playback Playback) *FrameType {
+	c := *f
+	c.playback = playback
+	c.variants = new(sync.Map)
+	return &c
+}
+
 // DefaultFrameType creates a new FrameType with default 70ms timing
 func DefaultFrameType(frames []string) *FrameType {
-	return &FrameType{
-		frames: frames,
-		sleep:  70 * time.Millisecond,
-	}
+	return NewFrameType(frames, DefaultSleep)
 }
 
 // NewFrameType creates a new FrameType with custom timing
 func NewFrameType(frames []string, sleep time.Duration) *FrameType {
 	return &FrameType{
-		frames: frames,
-		sleep:  sleep,
+		frames:   frames,
+		sleep:    sleep,
+		variants: new(sync.Map),
+	}
+}
+
+// NewTimedFrameType creates a new FrameType with one duration per frame.
+// Frames without a positive duration fall back to sleep.
+func NewTimedFrameType(frames []string, sleep time.Duration, durations []time.Duration) *FrameType {
+	f := NewFrameType(frames, sleep)
+	if len(durations) > 0 {
+		f.durations = make([]time.Duration, len(frames))
+		copy(f.durations, durations)
+	}
+	return f
+}
+
+// NewHeldFrameType creates a new FrameType that uses sleep for every frame
+// except those listed in holds, keyed by frame index
+func NewHeldFrameType(frames []string, sleep time.Duration, holds map[int]time.Duration) *FrameType {
+	var durations []time.Duration
+	for index, hold := range holds {
+		if index < 0 || index >= len(frames) {
+			continue
+		}
+		if durations == nil {
+			durations = make([]time.Duration, len(frames))
+		}
+		durations[index] = hold
 	}
+	return NewTimedFrameType(frames, sleep, durations)
 }
 
-// FrameMap registry for all available animations
-var FrameMap = map[string]*FrameType{
-	"seal": DefaultFrameType(SealWiggleFrames),
-}
\ No newline at end of file
+// FrameMap registry for all available animations, populated from the
+// embedded assets
+var FrameMap = map[string]*FrameType{}
@@ -0,0 +1,50 @@
+package animations
+
+import (
+	"image/color"
+
+	"seal-ascii/convert"
+)
+
+// Glyphs returns the animation redrawn with charset instead of braille.
+// Each charset's variant is built once and reused. Its frames are no longer
+// braille, so fit and retime the animation before picking a charset.
+func (f *FrameType) Glyphs(charset convert.Charset) *FrameType {
+	if charset == f.charset || f.charset != convert.Braille {
+		return f
+	}
+	if recoded, ok := f.variants.Load(charset); ok {
+		return recoded.(*FrameType)
+	}
+
+	frames := make([]string, len(f.frames))
+	var colors, backgrounds [][][]color.RGBA
+	if f.HasColor() {
+		colors = make([][][]color.RGBA, len(f.frames))
+		backgrounds = make([][][]color.RGBA, len(f.frames))
+	}
+	for i, frame := range f.frames {
+		var fg, bg [][]color.RGBA
+		frames[i], fg, bg = convert.Recode(frame, f.GetColors(i), charset)
+		if colors != nil {
+			colors[i], backgrounds[i] = fg, bg
+		}
+	}
+
+	if charset != convert.HalfBlock {
+		backgrounds = nil
+	}
+	recoded := f.derive(frames, colors, backgrounds, f.sleep, f.durations)
+	recoded.charset = charset
+	stored, _ := f.variants.LoadOrStore(charset, recoded)
+	return stored.(*FrameType)
+}
+
+// Charsets lists the charsets the animation can be drawn with. Braille
+// animations can be recoded into any of them.
+func (f *FrameType) Charsets() []convert.Charset {
+	if f.charset != convert.Braille {
+		return []convert.Charset{f.charset}
+	}
+	return convert.Charsets
+}
@@ -0,0 +1,72 @@
+package animations
+
+import (
+	"math"
+	"sort"
+)
+
+// Info describes an animation in the JSON API. Field names are part of the
+// versioned API, add fields rather than renaming them.
+type Info struct {
+	Name       string   `json:"name"`
+	Frames     int      `json:"frames"`
+	Width      int      `json:"width"`       // in cells
+	Height     int      `json:"height"`      // in cells
+	DurationMS int64    `json:"duration_ms"` // one pass at the default timing
+	FPS        float64  `json:"fps"`         // average frames per second at the default timing
+	Charsets   []string `json:"charsets"`
+	Color      bool     `json:"color"`
+	Mode       string   `json:"mode"`  // default playback mode
+	Loops      int      `json:"loops"` // default loop count, 0 loops forever
+}
+
+// Catalog is the JSON API's list of animations, sorted by name
+type Catalog struct {
+	Animations []Info `json:"animations"`
+}
+
+// Describe returns the Info of the animation registered as name
+func (f *FrameType) Describe(name string) Info {
+	width, height := f.Size()
+	duration := f.GetDuration()
+	var fps float64
+	if duration > 0 {
+		fps = float64(f.GetLength()) / duration.Seconds()
+	}
+
+	charsets := make([]string, 0, len(f.Charsets()))
+	for _, charset := range f.Charsets() {
+		charsets = append(charsets, charset.String())
+	}
+	return Info{
+		Name:       name,
+		Frames:     f.GetLength(),
+		Width:      width,
+		Height:     height,
+		DurationMS: duration.Milliseconds(),
+		FPS:        math.Round(fps*100) / 100,
+		Charsets:   charsets,
+		Color:      f.HasColor(),
+		Mode:       f.playback.Mode.String(),
+		Loops:      f.playback.Loops,
+	}
+}
+
+// Names returns the registered animation names in sorted order
+func Names() []string {
+	names := make([]string, 0, len(FrameMap))
+	for name := range FrameMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DescribeAll returns the Catalog of every registered animation
+func DescribeAll() Catalog {
+	catalog := Catalog{Animations: make([]Info, 0, len(FrameMap))}
+	for _, name := range Names() {
+		catalog.Animations = append(catalog.Animations, FrameMap[name].Describe(name))
+	}
+	return catalog
+}
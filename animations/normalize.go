@@ -0,0 +1,177 @@
+package animations
+
+import (
+	"image"
+	"image/color"
+	"strings"
+)
+
+// BrailleBlank is the empty braille cell the frames are padded with
+const BrailleBlank = '⠀'
+
+// isBlank reports whether a cell draws nothing
+func isBlank(r rune) bool {
+	return r == BrailleBlank || r == ' '
+}
+
+// frameLines splits a frame into lines of runes, dropping the trailing newline
+func frameLines(frame string) [][]rune {
+	lines := strings.Split(strings.TrimSuffix(frame, "\n"), "\n")
+	grid := make([][]rune, len(lines))
+	for i, line := range lines {
+		grid[i] = []rune(line)
+	}
+	return grid
+}
+
+// joinLines is the inverse of frameLines
+func joinLines(grid [][]rune) string {
+	var sb strings.Builder
+	for _, line := range grid {
+		sb.WriteString(string(line))
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// Bounds returns the smallest rectangle, in cells, that holds every
+// non-blank cell of every frame. It is empty if all frames are blank.
+func (f *FrameType) Bounds() image.Rectangle {
+	var bounds image.Rectangle
+	for _, frame := range f.frames {
+		for y, line := range frameLines(frame) {
+			for x, r := range line {
+				if !isBlank(r) {
+					bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+				}
+			}
+		}
+	}
+	return bounds
+}
+
+// Size returns the width and height, in cells, of the largest frame
+func (f *FrameType) Size() (int, int) {
+	var width, height int
+	for _, frame := range f.frames {
+		lines := frameLines(frame)
+		height = max(height, len(lines))
+		for _, line := range lines {
+			width = max(width, len(line))
+		}
+	}
+	return width, height
+}
+
+// Crop cuts every frame to rect, padding short lines with blanks so all
+// frames keep the same alignment
+func (f *FrameType) Crop(rect image.Rectangle) *FrameType {
+	if rect.Empty() {
+		return f
+	}
+
+	frames := make([]string, len(f.frames))
+	for i, frame := range f.frames {
+		lines := frameLines(frame)
+		grid := make([][]rune, 0, rect.Dy())
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			row := make([]rune, rect.Dx())
+			for x := range row {
+				row[x] = BrailleBlank
+				if y < len(lines) && rect.Min.X+x < len(lines[y]) {
+					row[x] = lines[y][rect.Min.X+x]
+				}
+			}
+			grid = append(grid, row)
+		}
+		frames[i] = joinLines(grid)
+	}
+
+	return f.derive(frames, cropGrids(f.colors, rect), cropGrids(f.backgrounds, rect), f.sleep, f.durations)
+}
+
+// cropGrids cuts each frame's colour grid to rect, cells outside a grid
+// being zero
+func cropGrids(grids [][][]color.RGBA, rect image.Rectangle) [][][]color.RGBA {
+	if len(grids) == 0 {
+		return nil
+	}
+	cropped := make([][][]color.RGBA, len(grids))
+	for i, src := range grids {
+		if src == nil {
+			continue
+		}
+		cropped[i] = make([][]color.RGBA, rect.Dy())
+		for y := range cropped[i] {
+			cropped[i][y] = make([]color.RGBA, rect.Dx())
+			if sy := rect.Min.Y + y; sy < len(src) && rect.Min.X < len(src[sy]) {
+				copy(cropped[i][y], src[sy][rect.Min.X:])
+			}
+		}
+	}
+	return cropped
+}
+
+// TrimTrailing drops blank cells from the end of every line. Leading cells
+// are kept so the drawing stays aligned.
+func (f *FrameType) TrimTrailing() *FrameType {
+	frames := make([]string, len(f.frames))
+	var colors, backgrounds [][][]color.RGBA
+	if f.HasColor() {
+		colors = make([][][]color.RGBA, len(f.frames))
+	}
+	if len(f.backgrounds) > 0 {
+		backgrounds = make([][][]color.RGBA, len(f.frames))
+	}
+	for i, frame := range f.frames {
+		grid := frameLines(frame)
+		ends := make([]int, len(grid))
+		for y, line := range grid {
+			end := len(line)
+			for end > 0 && isBlank(line[end-1]) {
+				end--
+			}
+			grid[y] = line[:end]
+			ends[y] = end
+		}
+		frames[i] = joinLines(grid)
+		if colors != nil {
+			colors[i] = trimGrid(f.GetColors(i), ends)
+		}
+		if backgrounds != nil {
+			backgrounds[i] = trimGrid(f.GetBackgrounds(i), ends)
+		}
+	}
+	return f.derive(frames, colors, backgrounds, f.sleep, f.durations)
+}
+
+// trimGrid cuts each row of a colour grid to the line length in ends
+func trimGrid(src [][]color.RGBA, ends []int) [][]color.RGBA {
+	if src == nil {
+		return nil
+	}
+	var trimmed [][]color.RGBA
+	for y, end := range ends {
+		if y < len(src) {
+			trimmed = append(trimmed, src[y][:min(end, len(src[y]))])
+		}
+	}
+	return trimmed
+}
+
+// Normalize crops the animation to the union bounding box of its frames,
+// grown by margin cells on each side, and optionally trims trailing blanks
+func (f *FrameType) Normalize(margin int, trim bool) *FrameType {
+	bounds := f.Bounds()
+	if bounds.Empty() {
+		return f
+	}
+
+	width, height := f.Size()
+	rect := bounds.Inset(-margin).Intersect(image.Rect(0, 0, width, height))
+	normalized := f.Crop(rect)
+	if trim {
+		normalized = normalized.TrimTrailing()
+	}
+	return normalized
+}
@@ -0,0 +1,148 @@
+package animations
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PlaybackMode is the order frames are visited in
+type PlaybackMode int
+
+const (
+	// Forward plays frames first to last
+	Forward PlaybackMode = iota
+	// Reverse plays frames last to first
+	Reverse
+	// PingPong plays forward then back again without repeating the ends
+	PingPong
+)
+
+// String returns the query string name of the mode
+func (m PlaybackMode) String() string {
+	switch m {
+	case Reverse:
+		return "reverse"
+	case PingPong:
+		return "pingpong"
+	}
+	return "forward"
+}
+
+// Playback describes how an animation is played through
+type Playback struct {
+	Mode  PlaybackMode
+	Loops int    // number of passes, 0 loops forever
+	Outro string // optional message written after a finite playback ends
+}
+
+// Finite reports whether the playback ends on its own
+func (p Playback) Finite() bool {
+	return p.Loops > 0
+}
+
+// Override applies client supplied mode and loops values, as taken from
+// ?mode= and ?loops=, on top of p. Empty values leave p unchanged.
+func (p Playback) Override(mode, loops string) (Playback, error) {
+	switch strings.ToLower(mode) {
+	case "":
+	case "loop", "forward":
+		p.Mode = Forward
+		p.Loops = 0
+	case "once":
+		p.Mode = Forward
+		p.Loops = 1
+	case "reverse":
+		p.Mode = Reverse
+	case "pingpong", "ping-pong", "bounce":
+		p.Mode = PingPong
+	default:
+		return p, fmt.Errorf("unknown playback mode %q", mode)
+	}
+
+	switch strings.ToLower(loops) {
+	case "":
+	case "inf", "infinite", "forever":
+		p.Loops = 0
+	default:
+		n, err := strconv.Atoi(loops)
+		if err != nil || n < 0 {
+			return p, fmt.Errorf("invalid loop count %q", loops)
+		}
+		p.Loops = n
+	}
+	return p, nil
+}
+
+// Sequence steps through frame indices according to a Playback
+type Sequence struct {
+	length   int
+	playback Playback
+	pos      int // position within the current pass
+	pass     int // completed passes
+	done     bool
+}
+
+// NewSequence creates a Sequence over length frames
+func NewSequence(length int, playback Playback) *Sequence {
+	return &Sequence{length: length, playback: playback, done: length == 0}
+}
+
+// passLength is the number of frames shown in one pass
+func (s *Sequence) passLength() int {
+	if s.playback.Mode == PingPong && s.length > 1 {
+		return 2*s.length - 2
+	}
+	return s.length
+}
+
+// index maps a position within a pass to a frame index
+func (s *Sequence) index(pos int) int {
+	switch s.playback.Mode {
+	case Reverse:
+		return s.length - 1 - pos
+	case PingPong:
+		if pos >= s.length {
+			return 2*s.length - 2 - pos
+		}
+	}
+	return pos
+}
+
+// Next returns the next frame index, or false once a finite playback is over.
+// A finite ping-pong finishes back on the first frame.
+func (s *Sequence) Next() (int, bool) {
+	if s.done {
+		return 0, false
+	}
+
+	if s.pos == s.passLength() {
+		s.pos = 0
+		s.pass++
+		if s.playback.Finite() && s.pass >= s.playback.Loops {
+			s.done = true
+			if s.playback.Mode == PingPong && s.length > 1 {
+				return 0, true
+			}
+			return 0, false
+		}
+	}
+
+	index := s.index(s.pos)
+	s.pos++
+	return index, true
+}
+
+// Seek moves the sequence so Next returns index, counting within the current
+// pass. A finished sequence plays on to the end of that pass again.
+func (s *Sequence) Seek(index int) {
+	if s.length == 0 {
+		return
+	}
+	index = min(max(index, 0), s.length-1)
+	s.pos = index
+	if s.playback.Mode == Reverse {
+		s.pos = s.length - 1 - index
+	}
+	s.done = false
+}
@@ -0,0 +1,120 @@
+package animations
+
+import (
+	"reflect"
+	"testing"
+)
+
+// play collects up to limit indices from s, stopping early if it ends
+func play(s *Sequence, limit int) []int {
+	var got []int
+	for len(got) < limit {
+		index, ok := s.Next()
+		if !ok {
+			break
+		}
+		got = append(got, index)
+	}
+	return got
+}
+
+func TestSequenceNext(t *testing.T) {
+	tests := []struct {
+		name     string
+		length   int
+		playback Playback
+		want     []int // the whole playback, or the first ten of an endless one
+	}{
+		{"forward once", 4, Playback{Mode: Forward, Loops: 1}, []int{0, 1, 2, 3}},
+		{"forward twice", 3, Playback{Mode: Forward, Loops: 2}, []int{0, 1, 2, 0, 1, 2}},
+		{"forward forever", 3, Playback{Mode: Forward}, []int{0, 1, 2, 0, 1, 2, 0, 1, 2, 0}},
+		{"reverse once", 4, Playback{Mode: Reverse, Loops: 1}, []int{3, 2, 1, 0}},
+		{"reverse twice", 3, Playback{Mode: Reverse, Loops: 2}, []int{2, 1, 0, 2, 1, 0}},
+		{"pingpong once", 4, Playback{Mode: PingPong, Loops: 1}, []int{0, 1, 2, 3, 2, 1, 0}},
+		{"pingpong twice", 3, Playback{Mode: PingPong, Loops: 2}, []int{0, 1, 2, 1, 0, 1, 2, 1, 0}},
+		{"pingpong forever", 3, Playback{Mode: PingPong}, []int{0, 1, 2, 1, 0, 1, 2, 1, 0, 1}},
+		{"pingpong single frame", 1, Playback{Mode: PingPong, Loops: 2}, []int{0, 0}},
+		{"no frames", 0, Playback{Mode: Forward, Loops: 1}, nil},
+		{"no frames forever", 0, Playback{Mode: PingPong}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSequence(tt.length, tt.playback)
+			if got := play(s, 10); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Next() gave %v, want %v", got, tt.want)
+			}
+			if !tt.playback.Finite() && tt.length > 0 {
+				return
+			}
+			// A finished sequence stays finished
+			if index, ok := s.Next(); ok {
+				t.Errorf("Next() after the end gave %d", index)
+			}
+		})
+	}
+}
+
+func TestSequenceSeek(t *testing.T) {
+	tests := []struct {
+		name     string
+		playback Playback
+		seek     int
+		want     []int
+	}{
+		{"forward", Playback{Mode: Forward, Loops: 1}, 2, []int{2, 3}},
+		{"reverse", Playback{Mode: Reverse, Loops: 1}, 2, []int{2, 1, 0}},
+		{"pingpong", Playback{Mode: PingPong, Loops: 1}, 2, []int{2, 3, 2, 1, 0}},
+		{"past the end", Playback{Mode: Forward, Loops: 1}, 9, []int{3}},
+		{"before the start", Playback{Mode: Forward, Loops: 1}, -1, []int{0, 1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Seeking a finished sequence plays the rest of that pass again
+			s := NewSequence(4, tt.playback)
+			play(s, 100)
+			s.Seek(tt.seek)
+			if got := play(s, 100); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Next() after Seek(%d) gave %v, want %v", tt.seek, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlaybackOverride(t *testing.T) {
+	base := Playback{Mode: Reverse, Loops: 3, Outro: "bye"}
+	tests := []struct {
+		mode, loops string
+		want        Playback
+		err         string
+	}{
+		{"", "", base, ""},
+		{"loop", "", Playback{Mode: Forward, Outro: "bye"}, ""},
+		{"forward", "2", Playback{Mode: Forward, Loops: 2, Outro: "bye"}, ""},
+		{"once", "", Playback{Mode: Forward, Loops: 1, Outro: "bye"}, ""},
+		{"PingPong", "", Playback{Mode: PingPong, Loops: 3, Outro: "bye"}, ""},
+		{"bounce", "inf", Playback{Mode: PingPong, Outro: "bye"}, ""},
+		{"", "forever", Playback{Mode: Reverse, Outro: "bye"}, ""},
+		{"", "0", Playback{Mode: Reverse, Outro: "bye"}, ""},
+		{"sideways", "", Playback{}, `unknown playback mode "sideways"`},
+		{"", "-1", Playback{}, `invalid loop count "-1"`},
+		{"", "two", Playback{}, `invalid loop count "two"`},
+		{"", "1.5", Playback{}, `invalid loop count "1.5"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode+"/"+tt.loops, func(t *testing.T) {
+			got, err := base.Override(tt.mode, tt.loops)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("Override(%q, %q) error = %v, want %q", tt.mode, tt.loops, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Override(%q, %q): %v", tt.mode, tt.loops, err)
+			}
+			if got != tt.want {
+				t.Errorf("Override(%q, %q) = %+v, want %+v", tt.mode, tt.loops, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package animations
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+
+	"seal-ascii/convert"
+)
+
+const (
+	// MinCols and MinRows are the smallest size an animation is scaled to
+	MinCols = 16
+	MinRows = 4
+
+	// colBucket and rowBucket round requested sizes down so that nearby
+	// terminal sizes share one cached variant
+	colBucket = 8
+	rowBucket = 4
+)
+
+// bucket rounds a requested size down to its cache bucket
+func bucket(n, step, minimum int) int {
+	if n < minimum {
+		return minimum
+	}
+	return n / step * step
+}
+
+// Fit returns the animation scaled down, keeping its aspect ratio, to fit in
+// cols x rows cells. Zero means no limit in that direction. Sizes are
+// rounded down to buckets and each bucket's variant is built once and reused.
+// Only braille frames can be rescaled, other charsets are returned as is.
+func (f *FrameType) Fit(cols, rows int) *FrameType {
+	width, height := f.Size()
+	if width == 0 || height == 0 || f.charset != convert.Braille {
+		return f
+	}
+	if cols <= 0 {
+		cols = width
+	}
+	if rows <= 0 {
+		rows = height
+	}
+	if cols >= width && rows >= height {
+		return f
+	}
+
+	// Raising a small request to the minimum size may cover the whole
+	// animation, which is never scaled up
+	cols = bucket(cols, colBucket, MinCols)
+	rows = bucket(rows, rowBucket, MinRows)
+	if cols >= width && rows >= height {
+		return f
+	}
+	key := [2]int{cols, rows}
+	if fitted, ok := f.variants.Load(key); ok {
+		return fitted.(*FrameType)
+	}
+
+	// Braille dots are roughly square on screen, so scaling both axes by
+	// the same factor keeps the aspect ratio
+	scale := min(float64(cols)/float64(width), float64(rows)/float64(height), 1)
+	newWidth := max(int(float64(width)*scale), 1)
+	newHeight := max(int(float64(height)*scale), 1)
+
+	frames := make([]string, len(f.frames))
+	var colors [][][]color.RGBA
+	if f.HasColor() {
+		colors = make([][][]color.RGBA, len(f.frames))
+	}
+	for i, frame := range f.frames {
+		frames[i] = convert.Rescale(frame, newWidth, newHeight, convert.Ordered)
+		if src := f.GetColors(i); src != nil {
+			colors[i] = convert.RescaleColors(src, newWidth, newHeight)
+		}
+	}
+	fitted, _ := f.variants.LoadOrStore(key, f.derive(frames, colors, nil, f.sleep, f.durations))
+	return fitted.(*FrameType)
+}
+
+// ParseSize reads client supplied ?cols= and ?rows= values, empty values
+// mean no limit
+func ParseSize(cols, rows string) (int, int, error) {
+	parse := func(name, value string) (int, error) {
+		if value == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid %s %q", name, value)
+		}
+		return n, nil
+	}
+
+	c, err := parse("cols", cols)
+	if err != nil {
+		return 0, 0, err
+	}
+	r, err := parse("rows", rows)
+	if err != nil {
+		return 0, 0, err
+	}
+	return c, r, nil
+}
@@ -0,0 +1,21 @@
+package animations
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFitNeverGrows(t *testing.T) {
+	small := NewFrameType([]string{"⠁⠂\n"}, time.Second)
+	if got := small.Fit(1, 1); got != small {
+		cols, rows := got.Size()
+		t.Errorf("Fit(1, 1) of a 2x1 animation is %dx%d, want it unchanged", cols, rows)
+	}
+
+	// Wider than the minimum but only a line tall: only the width shrinks
+	line := NewFrameType([]string{"⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿⣿\n"}, time.Second)
+	cols, rows := line.Fit(16, 1).Size()
+	if cols != 16 || rows != 1 {
+		t.Errorf("Fit(16, 1) of a 32x1 animation is %dx%d, want 16x1", cols, rows)
+	}
+}
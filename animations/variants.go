@@ -0,0 +1,185 @@
+package animations
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"time"
+)
+
+// MinSleep is the shortest frame duration a derived animation will use
+const MinSleep = 10 * time.Millisecond
+
+// timings returns the duration of every frame
+func (f *FrameType) timings() []time.Duration {
+	durations := make([]time.Duration, len(f.frames))
+	for i := range durations {
+		durations[i] = f.GetSleep(i)
+	}
+	return durations
+}
+
+// derive builds a new animation from f with the given frames, colours,
+// backgrounds and durations, keeping f's playback and charset
+func (f *FrameType) derive(frames []string, colors, backgrounds [][][]color.RGBA, sleep time.Duration, durations []time.Duration) *FrameType {
+	d := NewTimedFrameType(frames, sleep, durations).WithPlayback(f.playback).WithColors(colors)
+	d.charset = f.charset
+	for _, grid := range backgrounds {
+		if grid != nil {
+			d.backgrounds = make([][][]color.RGBA, len(frames))
+			copy(d.backgrounds, backgrounds)
+			break
+		}
+	}
+	return d
+}
+
+// pick returns the frames, colours and backgrounds at indices
+func (f *FrameType) pick(indices []int) ([]string, [][][]color.RGBA, [][][]color.RGBA) {
+	frames := make([]string, len(indices))
+	colors := make([][][]color.RGBA, len(indices))
+	backgrounds := make([][][]color.RGBA, len(indices))
+	for k, i := range indices {
+		frames[k] = f.frames[i]
+		colors[k] = f.GetColors(i)
+		backgrounds[k] = f.GetBackgrounds(i)
+	}
+	return frames, colors, backgrounds
+}
+
+// Decimate keeps every nth frame. Each kept frame stays up for the frames it
+// replaces so the animation runs at the same speed.
+func (f *FrameType) Decimate(n int) *FrameType {
+	if n <= 1 || len(f.frames) == 0 {
+		return f
+	}
+
+	var indices []int
+	var durations []time.Duration
+	for i := 0; i < len(f.frames); i += n {
+		var d time.Duration
+		for j := i; j < i+n && j < len(f.frames); j++ {
+			d += f.GetSleep(j)
+		}
+		indices = append(indices, i)
+		durations = append(durations, d)
+	}
+	frames, colors, backgrounds := f.pick(indices)
+	return f.derive(frames, colors, backgrounds, f.sleep*time.Duration(n), durations)
+}
+
+// Resample returns an animation with exactly count evenly timed frames
+// covering the same total duration, dropping or repeating frames as needed
+func (f *FrameType) Resample(count int) *FrameType {
+	if count <= 0 || len(f.frames) == 0 {
+		return f
+	}
+
+	total := f.GetDuration()
+	sleep := total / time.Duration(count)
+	if sleep < MinSleep {
+		sleep = MinSleep
+	}
+
+	// Pick the frame on screen at the start of each output slot
+	durations := f.timings()
+	indices := make([]int, count)
+	source, elapsed := 0, time.Duration(0)
+	for k := range indices {
+		at := total * time.Duration(k) / time.Duration(count)
+		for source < len(f.frames)-1 && elapsed+durations[source] <= at {
+			elapsed += durations[source]
+			source++
+		}
+		indices[k] = source
+	}
+	frames, colors, backgrounds := f.pick(indices)
+	return f.derive(frames, colors, backgrounds, sleep, nil)
+}
+
+// WithFPS resamples the animation to play at fps frames per second
+func (f *FrameType) WithFPS(fps float64) *FrameType {
+	if fps <= 0 {
+		return f
+	}
+	count := int(f.GetDuration().Seconds()*fps + 0.5)
+	if count < 1 {
+		count = 1
+	}
+	return f.Resample(count)
+}
+
+// Speed scales playback speed by factor, 2 plays twice as fast
+func (f *FrameType) Speed(factor float64) *FrameType {
+	if factor <= 0 || factor == 1 {
+		return f
+	}
+
+	scale := func(d time.Duration) time.Duration {
+		d = time.Duration(float64(d) / factor)
+		if d < MinSleep {
+			d = MinSleep
+		}
+		return d
+	}
+
+	durations := f.timings()
+	for i, d := range durations {
+		durations[i] = scale(d)
+	}
+	return f.derive(f.frames, f.colors, f.backgrounds, scale(f.sleep), durations)
+}
+
+// Slice returns the frames in [start, end), clamped to the animation
+func (f *FrameType) Slice(start, end int) *FrameType {
+	if start < 0 {
+		start = 0
+	}
+	if end > len(f.frames) {
+		end = len(f.frames)
+	}
+	if start >= end {
+		return f
+	}
+
+	var durations []time.Duration
+	if len(f.durations) > 0 {
+		durations = f.durations[start:end]
+	}
+	var colors, backgrounds [][][]color.RGBA
+	if len(f.colors) > 0 {
+		colors = f.colors[start:end]
+	}
+	if len(f.backgrounds) > 0 {
+		backgrounds = f.backgrounds[start:end]
+	}
+	return f.derive(f.frames[start:end], colors, backgrounds, f.sleep, durations)
+}
+
+// Retime applies client supplied fps and speed values, as taken from ?fps=
+// and ?speed=. Empty values leave the animation unchanged.
+func (f *FrameType) Retime(fps, speed string) (*FrameType, error) {
+	animation := f
+	if fps != "" {
+		v, err := strconv.ParseFloat(fps, 64)
+		if err != nil || !finite(v) || v <= 0 || v > float64(time.Second/MinSleep) {
+			return nil, fmt.Errorf("invalid fps %q", fps)
+		}
+		animation = animation.WithFPS(v)
+	}
+	if speed != "" {
+		v, err := strconv.ParseFloat(speed, 64)
+		if err != nil || !finite(v) || v <= 0 {
+			return nil, fmt.Errorf("invalid speed %q", speed)
+		}
+		animation = animation.Speed(v)
+	}
+	return animation, nil
+}
+
+// finite reports whether v is neither NaN nor infinite, which ParseFloat
+// accepts as "NaN" and "Inf"
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
@@ -1,152 +1,183 @@
 package handler
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
 	"strings"
 	"time"
+
+	"seal-ascii/animations"
+	"seal-ascii/negotiate"
+	"seal-ascii/web"
 )
 
 const (
-	clearScreen = "\033[2J\033[H"
-)
+	// footer is drawn under every frame
+	footer = "\n🦭 Wiggling braille seal! Press Ctrl+C to stop\n"
 
-// Selected 24 frames out of 187 total frames - optimized for Vercel deployment
-var SealWiggleFrames = []string{
-	`⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀
-⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⢀⢀⢀⡀⣀⢀⡀⣀⢀⡀⣀⢀⡀⣀⢀⡀⣀⢀⡀⣀⢀⡀⣀⢀⡀⣀⢀⡀⣀⢀⡀⣀⢀⡀⣀⢀⡀⣀⢀⡀⣀⢀⡀⣀⢀⡀⣀⢀⡀⣀⢀⡀⣀⢀⡀⣀⢀⡀⣀⢀⡀⣀⢀⢀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀
-⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⢠⢰⢕⢗⢕⢗⢝⢎⢗⢝⢎⢗⢝⢎⢗⢝⢎⢗⢝⢎⢗⢝⢎⢗⢝⢎⢗⢝⢎⢗⢝⢎⢗⢝⢎⢗⢝⢎⢗⢝⢎⢗⢝⢎⢗⢝⢎⢗⢝⢎⢗⢝⢎⢗⢝⢎⢗⢝⢎⢗⢝⢎⢗⢝⢎⢗⡳⡕⣕⡄⠄⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀`,
+	// basePath is where vercel.json mounts the function
+	basePath = "/silly_seal"
 
-	// Additional 23 frames would go here...
-	// For brevity, I'll just include a sample and the structure
-	// In the actual deployment, you'd copy all frames from vercel_frames_compact.txt
-}
+	// writeTimeout bounds how long a single frame write may block on a slow
+	// or vanished client
+	writeTimeout = 10 * time.Second
+
+	// maxDuration cuts streams short of the Vercel function timeout
+	maxDuration = 15 * time.Second
+
+	// timeout is written when maxDuration cuts a stream
+	timeout = "\n\n🦭 Thanks for watching! Run the command again for more seal wiggling!"
+)
+
+// negotiator picks each client's output, with extra User-Agents taken from
+// the environment
+var negotiator = negotiate.FromEnv(os.Getenv)
 
 func Handler(w http.ResponseWriter, r *http.Request) {
 	// Get the path from query parameter (set by Vercel rewrite) or URL path
 	path := r.URL.Query().Get("path")
 	if path == "" {
-		path = strings.TrimPrefix(r.URL.Path, "/silly_seal")
-		if path == "" {
-			path = "/"
-		}
+		path = strings.TrimPrefix(r.URL.Path, basePath)
 	}
+	path = strings.Trim(path, "/")
 
 	// Handle list endpoint
-	if path == "/list" || path == "list" {
-		w.Header().Set("Content-Type", "application/json")
-		response := map[string]interface{}{
-			"animations": []string{"seal"},
-			"usage":      "curl https://your-deployment.vercel.app/silly_seal",
-			"example":    "curl https://your-deployment.vercel.app/silly_seal",
-		}
-		json.NewEncoder(w).Encode(response)
+	if path == "list" {
+		web.ServeList(w, "curl https://your-deployment.vercel.app/silly_seal", "curl https://your-deployment.vercel.app/silly_seal")
 		return
 	}
 
-	// Detect if request is from curl or similar terminal client
-	userAgent := r.Header.Get("User-Agent")
-	isCurl := strings.Contains(strings.ToLower(userAgent), "curl") ||
-		strings.Contains(strings.ToLower(userAgent), "wget") ||
-		strings.Contains(strings.ToLower(userAgent), "httpie")
-
-	if !isCurl {
-		// Serve HTML page for browsers
-		w.Header().Set("Content-Type", "text/html")
-		html := `<!DOCTYPE html>
-<html>
-<head>
-    <title>🦭 Seal ASCII Animation</title>
-    <style>
-        body { 
-            background: #000; 
-            color: #0f0; 
-            font-family: 'Courier New', monospace; 
-            margin: 0; 
-            padding: 20px; 
-            text-align: center;
-        }
-        .container { max-width: 800px; margin: 0 auto; }
-        pre { 
-            font-size: 14px; 
-            line-height: 1.2; 
-            white-space: pre; 
-            background: #111;
-            padding: 20px;
-            border-radius: 8px;
-            margin: 20px 0;
-        }
-        code {
-            background: #222;
-            padding: 4px 8px;
-            border-radius: 4px;
-            color: #ff6;
-        }
-        h1 { color: #4af; }
-        a { color: #4af; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <h1>🦭 Wiggling Seal Animation</h1>
-        <p>Use curl to view the animated seal:</p>
-        <pre><code>curl https://your-deployment.vercel.app/silly_seal</code></pre>
-        <p>Or try:</p>
-        <pre><code>curl https://your-deployment.vercel.app/silly_seal/list</code></pre>
-        <p>Made with ❤️ inspired by parrot.live</p>
-        <p>🎯 24 frames selected from 187 total braille frames for optimal Vercel performance</p>
-    </div>
-</body>
-</html>`
-		fmt.Fprint(w, html)
+	// Versioned JSON API, the same routes the mux server has
+	if rest, ok := strings.CutPrefix(path, "api/v1/"); ok || path == "api/v1" {
+		serveV1(w, r, rest)
 		return
 	}
 
-	// Set headers for streaming
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	w.Header().Set("Cache-Control", "no-cache")
-
-	// Get flusher for immediate response (if available)
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		// Fallback for environments that don't support flushing
-		// Show a few frames in sequence
-		for i := 0; i < 3; i++ {
-			for _, frame := range SealWiggleFrames {
-				fmt.Fprint(w, clearScreen)
-				fmt.Fprint(w, frame)
-				fmt.Fprint(w, "\n\n")
-				time.Sleep(200 * time.Millisecond)
-			}
-		}
+	// Recordings and images, as /{animation}.cast and /{animation}.svg on
+	// the mux server
+	name, isCast := strings.CutSuffix(path, ".cast")
+	name, isSVG := strings.CutSuffix(name, ".svg")
+	if isCast {
+		web.ServeCast(w, name, r.URL.Query())
+		return
+	}
+	if isSVG {
+		web.ServeSVG(w, name, r.URL.Query())
+		return
+	}
+
+	// Resolve the animation named by the path, like the mux server does
+	animationName := path
+	if animationName == "" {
+		animationName = "seal" // Default animation
+	}
+
+	animation, exists := animations.FrameMap[animationName]
+	if !exists {
+		http.Error(w, fmt.Sprintf("Animation '%s' not found", animationName), http.StatusNotFound)
 		return
 	}
 
-	// Animation loop with streaming
-	frameIndex := 0
-	maxDuration := 15 * time.Second // Limit for Vercel function timeout
-	startTime := time.Now()
-	ticker := time.NewTicker(120 * time.Millisecond) // Optimized timing
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			if time.Since(startTime) > maxDuration {
-				fmt.Fprint(w, "\n\n🦭 Thanks for watching! Run the command again for more seal wiggling!")
-				return
-			}
-
-			// Clear screen and display current frame
-			fmt.Fprint(w, clearScreen)
-			fmt.Fprint(w, SealWiggleFrames[frameIndex])
-			fmt.Fprint(w, "\n\n🦭 Wiggling braille seal! Press Ctrl+C to stop\n")
-
-			flusher.Flush()
-
-			// Move to next frame
-			frameIndex = (frameIndex + 1) % len(SealWiggleFrames)
+	// Pick the output from ?format=, the Accept header and the User-Agent
+	w.Header().Add("Vary", "Accept, User-Agent")
+	profile, err := negotiator.Negotiate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if profile == negotiate.JSON {
+		web.WriteJSON(w, http.StatusOK, animation.Describe(animationName))
+		return
+	}
+
+	if profile == negotiate.HTML {
+		// Browsers get a player that fetches the frames as JSON
+		curlURL := "https://" + r.Host + basePath + "/" + animationName
+		web.ServePlayer(w, web.NewPlayer(animationName, animation, r.URL.Query(), basePath, curlURL))
+		return
+	}
+
+	// Size, time and colour the frames for the client's terminal, leaving
+	// room for the footer and cursor line
+	resolved, err := web.Resolve(animation, r.URL.Query(), r.Header, footer, 3)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Plain text clients get the first frame alone, without escape sequences
+	// or a footer
+	if profile == negotiate.Plain {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, resolved.Animation.GetFrame(0))
+		return
+	}
+
+	// Event stream clients get the frames as text, timed by the function.
+	// EventSource reconnects when the time limit cuts the stream and
+	// carries on from the last event id.
+	if profile == negotiate.SSE {
+		web.Events{
+			Animation:    resolved.Animation,
+			Playback:     resolved.Playback,
+			MaxDuration:  maxDuration,
+			WriteTimeout: writeTimeout,
+		}.ServeHTTP(w, r)
+		return
+	}
+
+	// Streams are cut short of the function time limit, writers that can't
+	// flush get a few passes at once
+	web.Stream{
+		Animation:    resolved.Animation,
+		Frames:       resolved.Frames,
+		Playback:     resolved.Playback,
+		MaxDuration:  maxDuration,
+		Timeout:      timeout,
+		WriteTimeout: writeTimeout,
+	}.ServeHTTP(w, r)
+}
+
+// serveV1 answers the JSON API request for rest, the path below /api/v1/
+func serveV1(w http.ResponseWriter, r *http.Request, rest string) {
+	name, isAnimation := strings.CutPrefix(rest, "animations/")
+	name, isFrames := strings.CutSuffix(name, "/frames")
+	name, isEvents := strings.CutSuffix(name, "/events")
+	animation := animations.FrameMap[name]
+	switch {
+	case rest == "animations":
+		web.WriteJSON(w, http.StatusOK, animations.DescribeAll())
+	case isAnimation && animation == nil:
+		web.WriteError(w, http.StatusNotFound, fmt.Sprintf("animation %q not found", name))
+	case isAnimation && isFrames:
+		animation, err := web.Variant(animation, r.URL.Query())
+		if err != nil {
+			web.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		web.WriteJSON(w, http.StatusOK, animation.Export(name))
+	case isAnimation && isEvents:
+		query := r.URL.Query()
+		animation, err := web.Variant(animation, query)
+		if err != nil {
+			web.WriteError(w, http.StatusBadRequest, err.Error())
+			return
 		}
+		playback, err := animation.GetPlayback().Override(query.Get("mode"), query.Get("loops"))
+		if err != nil {
+			web.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		web.Events{
+			Animation:    animation,
+			Playback:     playback,
+			MaxDuration:  maxDuration,
+			WriteTimeout: writeTimeout,
+		}.ServeHTTP(w, r)
+	case isAnimation:
+		web.WriteJSON(w, http.StatusOK, animation.Describe(name))
+	default:
+		web.WriteError(w, http.StatusNotFound, "no such endpoint")
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"seal-ascii/animations"
+	"seal-ascii/web"
+	"seal-ascii/web/webtest"
+)
+
+func TestHandlerRoutes(t *testing.T) {
+	webtest.Register(t)
+
+	list := webtest.List("curl https://your-deployment.vercel.app/silly_seal", "curl https://your-deployment.vercel.app/silly_seal")
+	routes := append(webtest.Routes(t, list),
+		webtest.Route{Path: "/api/v1", Status: http.StatusNotFound, Want: web.Error{Error: "no such endpoint"}},
+		// The Vercel rewrite passes the path as a query parameter
+		webtest.Route{Path: "/?path=dots&format=text", Status: http.StatusOK, Text: "⠁⠂\n"},
+		webtest.Route{Path: "/?path=api/v1/animations/dots", Status: http.StatusOK, Want: animations.FrameMap["dots"].Describe("dots")},
+	)
+	webtest.CheckRoutes(t, http.HandlerFunc(Handler), basePath, routes)
+}
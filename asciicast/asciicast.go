@@ -0,0 +1,122 @@
+// Package asciicast converts animations to and from asciinema's asciicast
+// v2 recordings: a JSON header line followed by one JSON array per output
+// event, [seconds, "o", data].
+package asciicast
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+
+	"seal-ascii/animations"
+	"seal-ascii/render"
+)
+
+const (
+	// ContentType is the media type asciinema serves recordings with
+	ContentType = "application/x-asciicast"
+
+	// Version is the asciicast format version written and read
+	Version = 2
+
+	hideCursor = "\033[?25l"
+	showCursor = "\033[?25h"
+)
+
+// Header is the first line of a recording
+type Header struct {
+	Version       int               `json:"version"`
+	Width         int               `json:"width"`  // in cells
+	Height        int               `json:"height"` // in cells
+	Timestamp     int64             `json:"timestamp,omitempty"`
+	Duration      float64           `json:"duration,omitempty"`        // in seconds
+	IdleTimeLimit float64           `json:"idle_time_limit,omitempty"` // longest pause on playback, in seconds
+	Title         string            `json:"title,omitempty"`
+	Env           map[string]string `json:"env,omitempty"`
+}
+
+// Options control how an animation is recorded
+type Options struct {
+	Title     string
+	Color     render.ColorMode
+	Playback  animations.Playback // an endless playback is recorded for one pass
+	Timestamp time.Time           // recording time, left out if zero
+}
+
+// event is one line after the header
+type event struct {
+	time float64
+	data string
+}
+
+func (e event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{e.time, "o", e.data})
+}
+
+// Encode writes animation to w as a recording that shows each frame for its
+// duration. The terminal is one line taller than the frames so drawing the
+// last line doesn't scroll them, and the last event, which shows the cursor
+// again along with any outro, comes when the last frame is due to end.
+// Events are written as they are rendered, so long playbacks don't build up
+// in memory.
+func Encode(w io.Writer, animation *animations.FrameType, opts Options) error {
+	playback := opts.Playback
+	if !playback.Finite() {
+		playback.Loops = 1
+		playback.Outro = ""
+	}
+
+	// The header carries the duration, so step through once for it first
+	var duration time.Duration
+	sequence := animations.NewSequence(animation.GetLength(), playback)
+	for {
+		index, ok := sequence.Next()
+		if !ok {
+			break
+		}
+		duration += animation.GetSleep(index)
+	}
+
+	width, height := animation.Size()
+	header := Header{
+		Version:  Version,
+		Width:    max(width, 1),
+		Height:   height + 1,
+		Duration: duration.Seconds(),
+		Title:    opts.Title,
+		Env:      map[string]string{"TERM": "xterm-256color"},
+	}
+	if !opts.Timestamp.IsZero() {
+		header.Timestamp = opts.Timestamp.Unix()
+	}
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+	if err := enc.Encode(event{0, hideCursor}); err != nil {
+		return err
+	}
+
+	// Recordings play without a tty, so newlines need their carriage returns
+	stream := render.NewCache(animation, "", opts.Color).Stream(true)
+	sequence = animations.NewSequence(animation.GetLength(), playback)
+	var elapsed time.Duration
+	for {
+		index, ok := sequence.Next()
+		if !ok {
+			break
+		}
+		data := bytes.ReplaceAll(stream.Render(index), []byte("\n"), []byte("\r\n"))
+		if err := enc.Encode(event{elapsed.Seconds(), string(data)}); err != nil {
+			return err
+		}
+		elapsed += animation.GetSleep(index)
+	}
+	end := showCursor
+	if playback.Outro != "" {
+		end += playback.Outro + "\r\n"
+	}
+	return enc.Encode(event{elapsed.Seconds(), end})
+}
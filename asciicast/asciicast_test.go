@@ -0,0 +1,117 @@
+package asciicast
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"seal-ascii/animations"
+	"seal-ascii/render"
+)
+
+// trimmed drops the blank cells Decode trims from the end of each line
+func trimmed(frame string) string {
+	lines := strings.Split(frame, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, string(animations.BrailleBlank)+" ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestRoundTrip(t *testing.T) {
+	seal := animations.FrameMap["seal"]
+	var buf bytes.Buffer
+	opts := Options{Title: "seal", Color: render.NoColor, Playback: animations.Playback{Mode: animations.Forward, Loops: 1}}
+	if err := Encode(&buf, seal, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, header, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	width, height := seal.Size()
+	if header.Width != width || header.Height != height+1 || header.Title != "seal" {
+		t.Errorf("header = %+v, want %dx%d titled seal", header, width, height+1)
+	}
+	if decoded.GetLength() != seal.GetLength() {
+		t.Fatalf("decoded %d frames, want %d", decoded.GetLength(), seal.GetLength())
+	}
+	for i := range seal.GetLength() {
+		if got, want := decoded.GetFrame(i), trimmed(seal.GetFrame(i)); got != want {
+			t.Fatalf("frame %d differs:\n%s\nwant\n%s", i, got, want)
+		}
+		if got, want := decoded.GetSleep(i), seal.GetSleep(i); got != want {
+			t.Errorf("frame %d lasts %s, want %s", i, got, want)
+		}
+	}
+}
+
+// recording is a 4x1 terminal drawing a, b and c at 0s, 1s and 6s
+const recording = `
+[0, "o", "a"]
+[1, "o", "\rb"]
+[6, "o", "\rc"]
+`
+
+func TestDecodeIdleTimeLimit(t *testing.T) {
+	// The last frame lasts until the recording's duration, or the default
+	// without one
+	tests := []struct {
+		name   string
+		header string
+		want   []time.Duration
+	}{
+		{"no limit", `{"version":2,"width":4,"height":1}`, []time.Duration{time.Second, 5 * time.Second, animations.DefaultSleep}},
+		{"limit", `{"version":2,"width":4,"height":1,"idle_time_limit":2}`, []time.Duration{time.Second, 2 * time.Second, animations.DefaultSleep}},
+		{"duration", `{"version":2,"width":4,"height":1,"duration":9}`, []time.Duration{time.Second, 5 * time.Second, 3 * time.Second}},
+		{"limit and duration", `{"version":2,"width":4,"height":1,"duration":9,"idle_time_limit":2}`, []time.Duration{time.Second, 2 * time.Second, animations.DefaultSleep}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			animation, _, err := Decode(strings.NewReader(tt.header + recording))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if animation.GetLength() != len(tt.want) {
+				t.Fatalf("%d frames, want %d", animation.GetLength(), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got := animation.GetSleep(i); got != want {
+					t.Errorf("frame %d lasts %s, want %s", i, got, want)
+				}
+			}
+			if got := animation.GetFrame(1); got != "b\n" {
+				t.Errorf("frame 1 = %q, want %q", got, "b\n")
+			}
+		})
+	}
+}
+
+func TestDecodeMaxFrames(t *testing.T) {
+	// Distinct screens a second apart, one more than fit
+	write := func(n int) string {
+		var sb strings.Builder
+		sb.WriteString(`{"version":2,"width":4,"height":1}` + "\n")
+		for i := range n {
+			fmt.Fprintf(&sb, "[%d, \"o\", \"\\r%04d\"]\n", i+1, i)
+		}
+		return sb.String()
+	}
+
+	animation, _, err := Decode(strings.NewReader(write(MaxFrames - 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The blank screen before the first event is a frame too
+	if animation.GetLength() != MaxFrames {
+		t.Errorf("%d frames, want %d", animation.GetLength(), MaxFrames)
+	}
+
+	_, _, err = Decode(strings.NewReader(write(MaxFrames)))
+	if want := fmt.Sprintf("more than %d frames", MaxFrames); err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,207 @@
+package asciicast
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"seal-ascii/animations"
+	"seal-ascii/convert"
+)
+
+const (
+	// MaxWidth and MaxHeight bound the terminal a recording may ask for
+	MaxWidth  = 1000
+	MaxHeight = 500
+
+	// MaxFrames bounds the frames kept from one recording
+	MaxFrames = 10000
+
+	// maxLine bounds one line of the file, a whole screen's worth of output
+	// with escape sequences fits many times over
+	maxLine = 16 << 20
+)
+
+// DefaultForeground is the colour given to cells printed in the terminal's
+// default colour when other cells of the frame are coloured
+var DefaultForeground = color.RGBA{0xe5, 0xe5, 0xe5, 0xff}
+
+// shot is one distinct screen and when it appeared
+type shot struct {
+	at   time.Duration
+	grid [][]cell
+}
+
+// Decode reads a recording and replays its output, keeping each distinct
+// screen as a frame that lasts until the next one appears. Screens that are
+// replaced within MinSleep, such as a frame caught half drawn, are dropped.
+// Frames made of braille and spaces only are braille and can be fitted and
+// recoded, anything else is kept as plain text.
+func Decode(r io.Reader) (*animations.FrameType, Header, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLine)
+
+	var header Header
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, header, err
+		}
+		return nil, header, errors.New("empty recording")
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return nil, header, fmt.Errorf("header: %w", err)
+	}
+	if header.Version != Version {
+		return nil, header, fmt.Errorf("unsupported asciicast version %d", header.Version)
+	}
+	if header.Width < 1 || header.Width > MaxWidth || header.Height < 1 || header.Height > MaxHeight {
+		return nil, header, fmt.Errorf("invalid terminal size %dx%d", header.Width, header.Height)
+	}
+
+	s := newScreen(header.Width, header.Height)
+	shots := []shot{{0, s.snapshot()}}
+	var end, prev time.Duration
+	for line := 2; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var e []any
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, header, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(e) != 3 {
+			return nil, header, fmt.Errorf("line %d: event has %d fields", line, len(e))
+		}
+		seconds, ok := e[0].(float64)
+		kind, _ := e[1].(string)
+		data, _ := e[2].(string)
+		if !ok || seconds < 0 {
+			return nil, header, fmt.Errorf("line %d: invalid time", line)
+		}
+		// Pauses longer than the idle time limit play back at the limit
+		raw := toDuration(seconds)
+		gap := max(raw-prev, 0)
+		if limit := toDuration(header.IdleTimeLimit); limit > 0 {
+			gap = min(gap, limit)
+		}
+		prev = max(raw, prev)
+		at := end + gap
+		end = at
+		if kind != "o" {
+			continue // input, markers and resizes don't change the screen
+		}
+
+		s.Write(data)
+		last := &shots[len(shots)-1]
+		switch grid := s.snapshot(); {
+		case equal(grid, last.grid):
+		case at-last.at < animations.MinSleep:
+			last.grid = grid
+		case len(shots) == MaxFrames:
+			return nil, header, fmt.Errorf("more than %d frames", MaxFrames)
+		default:
+			shots = append(shots, shot{at, grid})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, header, err
+	}
+	if header.Duration > 0 && header.IdleTimeLimit == 0 {
+		end = max(end, toDuration(header.Duration))
+	}
+
+	return frameType(shots, end), header, nil
+}
+
+// frameType builds the animation from the screens, trimming the blank rows
+// under the lowest drawn cell of any frame
+func frameType(shots []shot, end time.Duration) *animations.FrameType {
+	rows := 1
+	braille := true
+	for _, shot := range shots {
+		for y, line := range shot.grid {
+			for _, c := range line {
+				if !isBlank(c.r) {
+					rows = max(rows, y+1)
+				}
+				braille = braille && (c.r == ' ' || c.r >= 0x2800 && c.r <= 0x28ff)
+			}
+		}
+	}
+
+	frames := make([]string, len(shots))
+	colors := make([][][]color.RGBA, len(shots))
+	durations := make([]time.Duration, len(shots))
+	for i, shot := range shots {
+		next := end
+		if i+1 < len(shots) {
+			next = shots[i+1].at
+		}
+		durations[i] = next - shot.at
+		frames[i], colors[i] = frame(shot.grid[:rows], braille)
+	}
+
+	charset := convert.ASCII
+	if braille {
+		charset = convert.Braille
+	}
+	return animations.NewTimedFrameType(frames, animations.DefaultSleep, durations).
+		WithColors(colors).
+		WithCharset(charset)
+}
+
+// frame returns the text of grid with trailing blanks trimmed from each
+// line, and its colours if any cell has one
+func frame(grid [][]cell, braille bool) (string, [][]color.RGBA) {
+	var sb strings.Builder
+	colors := make([][]color.RGBA, len(grid))
+	colored := false
+	for y, line := range grid {
+		n := len(line)
+		for n > 0 && isBlank(line[n-1].r) {
+			n--
+		}
+		colors[y] = make([]color.RGBA, n)
+		for x, c := range line[:n] {
+			r := c.r
+			if braille && r == ' ' {
+				r = animations.BrailleBlank
+			}
+			sb.WriteRune(r)
+			colors[y][x] = DefaultForeground
+			if c.colored {
+				colors[y][x] = c.fg
+				colored = true
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	if !colored {
+		return sb.String(), nil
+	}
+	return sb.String(), colors
+}
+
+// toDuration converts a time in the file to a duration, rounded to the
+// microseconds asciinema records
+func toDuration(s float64) time.Duration {
+	return time.Duration(math.Round(s*1e6)) * time.Microsecond
+}
+
+// isBlank reports whether a cell draws nothing
+func isBlank(r rune) bool {
+	return r == animations.BrailleBlank || unicode.IsSpace(r)
+}
+
+// equal reports whether two screens show the same thing
+func equal(a, b [][]cell) bool {
+	return slices.EqualFunc(a, b, func(x, y []cell) bool { return slices.Equal(x, y) })
+}
@@ -0,0 +1,307 @@
+package asciicast
+
+import (
+	"image/color"
+	"strconv"
+	"strings"
+
+	"seal-ascii/render"
+)
+
+// cell is one character on the screen with the colour it was written in
+type cell struct {
+	r       rune
+	fg      color.RGBA
+	colored bool
+}
+
+// blank is an empty cell in the default colour
+var blank = cell{r: ' '}
+
+// parser states
+const (
+	ground  = iota
+	escape  // after ESC
+	csi     // inside ESC [
+	osc     // inside ESC ], up to BEL or ST
+	oscEsc  // ESC inside an OSC, the start of ST
+	charset // after ESC ( and friends, one more byte to skip
+)
+
+// screen is a small terminal emulator, just enough to replay the output of
+// animation recordings: printing with line wrap and scrolling, cursor
+// movement, erasing and foreground colours. Everything else is ignored.
+type screen struct {
+	width, height int
+	grid          [][]cell
+	x, y          int
+
+	fg      color.RGBA
+	colored bool
+
+	state  int
+	params []byte // parameters of the CSI sequence being read
+}
+
+// newScreen creates a blank screen of width x height cells
+func newScreen(width, height int) *screen {
+	s := &screen{width: width, height: height, grid: make([][]cell, height)}
+	for y := range s.grid {
+		s.grid[y] = blankLine(width)
+	}
+	return s
+}
+
+func blankLine(width int) []cell {
+	line := make([]cell, width)
+	for x := range line {
+		line[x] = blank
+	}
+	return line
+}
+
+// Write feeds terminal output to the screen. Escape sequences may be split
+// across writes.
+func (s *screen) Write(data string) {
+	for _, r := range data {
+		switch s.state {
+		case ground:
+			s.ground(r)
+		case escape:
+			s.escape(r)
+		case csi:
+			if r >= 0x40 && r <= 0x7e {
+				s.state = ground
+				s.csi(r, string(s.params))
+				s.params = s.params[:0]
+			} else if len(s.params) < 64 {
+				s.params = append(s.params, byte(r))
+			}
+		case osc:
+			switch r {
+			case 0x07:
+				s.state = ground
+			case 0x1b:
+				s.state = oscEsc
+			}
+		case oscEsc:
+			s.state = osc
+			if r == '\\' {
+				s.state = ground
+			}
+		case charset:
+			s.state = ground
+		}
+	}
+}
+
+// ground handles a rune outside any escape sequence
+func (s *screen) ground(r rune) {
+	switch r {
+	case 0x1b:
+		s.state = escape
+	case '\r':
+		s.x = 0
+	case '\n', '\v', '\f':
+		s.lineFeed()
+	case '\b':
+		s.x = max(s.x-1, 0)
+	case '\t':
+		s.x = min((s.x/8+1)*8, s.width-1)
+	default:
+		if r < 0x20 || r == 0x7f {
+			return // BEL and the other controls draw nothing
+		}
+		if s.x >= s.width {
+			s.x = 0
+			s.lineFeed()
+		}
+		s.grid[s.y][s.x] = cell{r: r, fg: s.fg, colored: s.colored}
+		s.x++
+	}
+}
+
+// escape handles the rune after ESC
+func (s *screen) escape(r rune) {
+	s.state = ground
+	switch r {
+	case '[':
+		s.state = csi
+	case ']':
+		s.state = osc
+	case '(', ')', '*', '+':
+		s.state = charset
+	case 'c':
+		s.reset()
+	case 'D':
+		s.lineFeed()
+	case 'E':
+		s.x = 0
+		s.lineFeed()
+	case 'M':
+		if s.y == 0 {
+			copy(s.grid[1:], s.grid[:s.height-1])
+			s.grid[0] = blankLine(s.width)
+		} else {
+			s.y--
+		}
+	}
+}
+
+// csi handles a complete ESC [ params final sequence
+func (s *screen) csi(final rune, params string) {
+	if strings.HasPrefix(params, "?") {
+		// Private modes: only the alternate screen changes what is shown
+		if (final == 'h' || final == 'l') && (params == "?1049" || params == "?47" || params == "?1047") {
+			s.erase(0, 0, s.width, s.height)
+			s.x, s.y = 0, 0
+		}
+		return
+	}
+	args := parseParams(params)
+	arg := func(i, fallback int) int {
+		if i < len(args) && args[i] > 0 {
+			return args[i]
+		}
+		return fallback
+	}
+
+	switch final {
+	case 'H', 'f':
+		s.moveTo(arg(1, 1)-1, arg(0, 1)-1)
+	case 'A':
+		s.moveTo(s.x, s.y-arg(0, 1))
+	case 'B', 'e':
+		s.moveTo(s.x, s.y+arg(0, 1))
+	case 'C', 'a':
+		s.moveTo(s.x+arg(0, 1), s.y)
+	case 'D':
+		s.moveTo(s.x-arg(0, 1), s.y)
+	case 'E':
+		s.moveTo(0, s.y+arg(0, 1))
+	case 'F':
+		s.moveTo(0, s.y-arg(0, 1))
+	case 'G', '`':
+		s.moveTo(arg(0, 1)-1, s.y)
+	case 'd':
+		s.moveTo(s.x, arg(0, 1)-1)
+	case 'J':
+		switch arg(0, 0) {
+		case 0:
+			s.erase(s.x, s.y, s.width, s.y+1)
+			s.erase(0, s.y+1, s.width, s.height)
+		case 1:
+			s.erase(0, 0, s.width, s.y)
+			s.erase(0, s.y, s.x+1, s.y+1)
+		case 2, 3:
+			s.erase(0, 0, s.width, s.height)
+		}
+	case 'K':
+		switch arg(0, 0) {
+		case 0:
+			s.erase(s.x, s.y, s.width, s.y+1)
+		case 1:
+			s.erase(0, s.y, s.x+1, s.y+1)
+		case 2:
+			s.erase(0, s.y, s.width, s.y+1)
+		}
+	case 'X':
+		s.erase(s.x, s.y, s.x+arg(0, 1), s.y+1)
+	case 'm':
+		s.sgr(args)
+	}
+}
+
+// sgr applies the foreground parts of a Select Graphic Rendition sequence.
+// Backgrounds and attributes are skipped along with their arguments.
+func (s *screen) sgr(args []int) {
+	if len(args) == 0 {
+		args = []int{0}
+	}
+	for i := 0; i < len(args); i++ {
+		switch a := args[i]; {
+		case a == 0 || a == 39:
+			s.fg, s.colored = color.RGBA{}, false
+		case a >= 30 && a <= 37:
+			s.fg, s.colored = render.Palette(a-30), true
+		case a >= 90 && a <= 97:
+			s.fg, s.colored = render.Palette(a-90+8), true
+		case a == 38 || a == 48:
+			var c color.RGBA
+			switch {
+			case i+2 < len(args) && args[i+1] == 5:
+				c = render.Palette(args[i+2])
+				i += 2
+			case i+4 < len(args) && args[i+1] == 2:
+				c = color.RGBA{clamp(args[i+2]), clamp(args[i+3]), clamp(args[i+4]), 0xff}
+				i += 4
+			default:
+				return
+			}
+			if a == 38 {
+				s.fg, s.colored = c, true
+			}
+		}
+	}
+}
+
+// lineFeed moves down a line, scrolling at the bottom of the screen
+func (s *screen) lineFeed() {
+	s.x = min(s.x, s.width-1) // leaving the line cancels a pending wrap
+	if s.y < s.height-1 {
+		s.y++
+		return
+	}
+	copy(s.grid, s.grid[1:])
+	s.grid[s.height-1] = blankLine(s.width)
+}
+
+// moveTo puts the cursor at x, y clamped to the screen
+func (s *screen) moveTo(x, y int) {
+	s.x = min(max(x, 0), s.width-1)
+	s.y = min(max(y, 0), s.height-1)
+}
+
+// erase blanks the cells from x0, y0 up to but not including x1, y1
+func (s *screen) erase(x0, y0, x1, y1 int) {
+	for y := max(y0, 0); y < min(y1, s.height); y++ {
+		for x := max(x0, 0); x < min(x1, s.width); x++ {
+			s.grid[y][x] = blank
+		}
+	}
+}
+
+// reset clears the screen and forgets the colour, as ESC c does
+func (s *screen) reset() {
+	s.erase(0, 0, s.width, s.height)
+	s.x, s.y = 0, 0
+	s.fg, s.colored = color.RGBA{}, false
+}
+
+// snapshot copies the cells on screen
+func (s *screen) snapshot() [][]cell {
+	grid := make([][]cell, s.height)
+	for y, line := range s.grid {
+		grid[y] = append([]cell(nil), line...)
+	}
+	return grid
+}
+
+// parseParams splits CSI parameters such as "1;5" into numbers, empty ones
+// being zero
+func parseParams(params string) []int {
+	if params == "" {
+		return nil
+	}
+	fields := strings.Split(strings.ReplaceAll(params, ":", ";"), ";")
+	args := make([]int, 0, len(fields))
+	for _, field := range fields {
+		n, _ := strconv.Atoi(field)
+		args = append(args, n)
+	}
+	return args
+}
+
+func clamp(v int) uint8 {
+	return uint8(min(max(v, 0), 255))
+}
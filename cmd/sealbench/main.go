@@ -0,0 +1,117 @@
+// Command sealbench opens many concurrent curl-like streams against a running
+// server and reports the throughput it sustains.
+//
+//	go run ./cmd/server &
+//	go run ./cmd/sealbench -url http://localhost:8081/seal -clients 2000 -duration 10s
+//
+// Thousands of clients need a raised open file limit (ulimit -n) on both
+// ends.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// countingWriter tallies bytes read from a stream
+type countingWriter struct {
+	n *atomic.Int64
+}
+
+func (c countingWriter) Write(p []byte) (int, error) {
+	c.n.Add(int64(len(p)))
+	return len(p), nil
+}
+
+func main() {
+	var (
+		url       = flag.String("url", "http://localhost:8081/seal", "stream URL")
+		clients   = flag.Int("clients", 1000, "number of concurrent streams")
+		duration  = flag.Duration("duration", 10*time.Second, "how long to stream")
+		rampUp    = flag.Duration("ramp", time.Second, "time taken to open all streams")
+		userAgent = flag.String("user-agent", "curl/8.4.0", "User-Agent sent with each request")
+	)
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("sealbench: ")
+
+	transport := &http.Transport{
+		MaxIdleConnsPerHost: *clients,
+		DisableCompression:  true,
+	}
+	client := &http.Client{Transport: transport}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *duration+*rampUp)
+	defer cancel()
+
+	var (
+		received  atomic.Int64
+		connected atomic.Int64
+		failed    atomic.Int64
+		wg        sync.WaitGroup
+	)
+
+	start := time.Now()
+	for i := 0; i < *clients; i++ {
+		wg.Add(1)
+		go func(delay time.Duration) {
+			defer wg.Done()
+			time.Sleep(delay)
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, *url, nil)
+			if err != nil {
+				failed.Add(1)
+				return
+			}
+			req.Header.Set("User-Agent", *userAgent)
+
+			resp, err := client.Do(req)
+			if err != nil {
+				failed.Add(1)
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				failed.Add(1)
+				return
+			}
+
+			connected.Add(1)
+			io.Copy(countingWriter{&received}, resp.Body)
+		}(*rampUp * time.Duration(i) / time.Duration(*clients))
+	}
+
+	// Report once a second while the streams run
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var last int64
+	for {
+		select {
+		case <-ticker.C:
+			total := received.Load()
+			fmt.Printf("%6.1fs  streams %5d  failed %4d  %8.2f MB/s\n",
+				time.Since(start).Seconds(), connected.Load(), failed.Load(), float64(total-last)/1e6)
+			last = total
+		case <-done:
+			elapsed := time.Since(start).Seconds()
+			total := received.Load()
+			fmt.Printf("\n%d streams, %d failed, %.2f MB in %.1fs (%.2f MB/s, %.1f KB/s per stream)\n",
+				connected.Load(), failed.Load(), float64(total)/1e6, elapsed,
+				float64(total)/1e6/elapsed, float64(total)/1e3/elapsed/float64(max(connected.Load(), 1)))
+			return
+		}
+	}
+}
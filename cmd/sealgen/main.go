@@ -0,0 +1,186 @@
+// Command sealgen converts a directory of PNG frames into the per-frame text
+// assets that the animations package embeds.
+//
+// It is normally run through go generate from the animations package:
+//
+//	go generate ./animations
+//
+// With -check it leaves the output untouched and exits non-zero if the
+// committed assets differ from what would be generated.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"seal-ascii/convert"
+)
+
+const (
+	frameExt = ".txt"   // extension of generated frame assets
+	colorExt = ".color" // extension of generated per-cell colours
+)
+
+func main() {
+	var (
+		dir       = flag.String("dir", "frames_img", "directory of PNG frames")
+		out       = flag.String("out", "", "output asset directory, e.g. animations/assets/seal")
+		width     = flag.Int("width", convert.DefaultWidth, "output width in braille cells")
+		height    = flag.Int("height", 0, "output height in braille cells (0 keeps aspect ratio)")
+		threshold = flag.Int("threshold", convert.DefaultThreshold, "brightness threshold 0-255")
+		dither    = flag.String("dither", convert.FloydSteinberg.String(), "dithering: none, floyd-steinberg or ordered")
+		invert    = flag.Bool("invert", false, "raise dots for dark pixels")
+		black     = flag.Int("black-point", convert.DefaultBlackPoint, "brightness 0-255 at or below which cells stay blank")
+		gamma     = flag.Float64("gamma", convert.DefaultGamma, "gamma applied to brightness above the black point")
+		colors    = flag.Bool("color", false, "also write per-cell colours taken from the images")
+		check     = flag.Bool("check", false, "fail if the output directory is stale instead of writing it")
+	)
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("sealgen: ")
+
+	if *out == "" {
+		log.Fatal("-out is required")
+	}
+	mode, ok := convert.ParseDither(*dither)
+	if !ok {
+		log.Fatalf("unknown dither mode %q", *dither)
+	}
+	if *threshold < 0 || *threshold > 255 {
+		log.Fatalf("threshold %d out of range", *threshold)
+	}
+	if *black < 0 || *black > 254 {
+		log.Fatalf("black point %d out of range", *black)
+	}
+	if !(*gamma > 0) || math.IsInf(*gamma, 0) {
+		log.Fatalf("invalid gamma %v", *gamma)
+	}
+
+	opts := convert.Options{
+		Width:     *width,
+		Height:    *height,
+		Threshold: uint8(*threshold),
+		Dither:    mode,
+		Invert:    *invert,
+
+		BlackPoint: uint8(*black),
+		Gamma:      *gamma,
+	}
+	files, err := generate(*dir, opts, *colors)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *check {
+		if err := compare(*out, files); err != nil {
+			log.Fatalf("%s is stale, run go generate: %v", *out, err)
+		}
+		return
+	}
+
+	if err := write(*out, files); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "sealgen: wrote %d files to %s\n", len(files), *out)
+}
+
+// generate converts every PNG in dir and returns the asset contents keyed by
+// output file name
+func generate(dir string, opts convert.Options, colors bool) (map[string][]byte, error) {
+	paths, err := convert.Files(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		img, err := convert.Decode(path)
+		if err != nil {
+			return nil, err
+		}
+		base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		files[base+frameExt] = []byte(convert.Image(img, opts))
+		if colors {
+			files[base+colorExt] = convert.FormatColors(convert.ImageColors(img, opts))
+		}
+	}
+	return files, nil
+}
+
+// existing lists the frame and colour assets currently in dir
+func existing(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (ext == frameExt || ext == colorExt) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// compare reports the first difference between dir and the generated files
+func compare(dir string, files map[string][]byte) error {
+	names, err := existing(dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, ok := files[name]; !ok {
+			return fmt.Errorf("unexpected %s", name)
+		}
+	}
+
+	want := make([]string, 0, len(files))
+	for name := range files {
+		want = append(want, name)
+	}
+	sort.Strings(want)
+	for _, name := range want {
+		current, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(current, files[name]) {
+			return fmt.Errorf("%s differs", name)
+		}
+	}
+	return nil
+}
+
+// write replaces the frame assets in dir with files
+func write(dir string, files map[string][]byte) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	names, err := existing(dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
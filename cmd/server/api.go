@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"seal-ascii/animations"
+	"seal-ascii/web"
+)
+
+// listAnimationsV1 serves GET /api/v1/animations
+func listAnimationsV1(w http.ResponseWriter, r *http.Request) {
+	web.WriteJSON(w, http.StatusOK, animations.DescribeAll())
+}
+
+// getAnimationV1 serves GET /api/v1/animations/{animation}
+func getAnimationV1(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["animation"]
+	animation, exists := animations.FrameMap[name]
+	if !exists {
+		web.WriteError(w, http.StatusNotFound, fmt.Sprintf("animation %q not found", name))
+		return
+	}
+	web.WriteJSON(w, http.StatusOK, animation.Describe(name))
+}
+
+// getFramesV1 serves GET /api/v1/animations/{animation}/frames. It takes
+// the same cols, rows, fps, speed and charset parameters as the stream.
+func getFramesV1(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["animation"]
+	animation, exists := animations.FrameMap[name]
+	if !exists {
+		web.WriteError(w, http.StatusNotFound, fmt.Sprintf("animation %q not found", name))
+		return
+	}
+	animation, err := web.Variant(animation, r.URL.Query())
+	if err != nil {
+		web.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	web.WriteJSON(w, http.StatusOK, animation.Export(name))
+}
+
+// getEventsV1 serves GET /api/v1/animations/{animation}/events, the frames
+// as Server-Sent Events. It takes the frames parameters plus mode and loops.
+func getEventsV1(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["animation"]
+	animation, exists := animations.FrameMap[name]
+	if !exists {
+		web.WriteError(w, http.StatusNotFound, fmt.Sprintf("animation %q not found", name))
+		return
+	}
+	query := r.URL.Query()
+	animation, err := web.Variant(animation, query)
+	if err != nil {
+		web.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	playback, err := animation.GetPlayback().Override(query.Get("mode"), query.Get("loops"))
+	if err != nil {
+		web.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	web.Events{
+		Animation:    animation,
+		Playback:     playback,
+		WriteTimeout: writeTimeout,
+		Closing:      closing,
+	}.ServeHTTP(w, r)
+}
+
+// getCast serves GET /{animation}.cast, an asciicast v2 recording. It takes
+// the frames parameters plus color, mode and loops.
+func getCast(w http.ResponseWriter, r *http.Request) {
+	web.ServeCast(w, mux.Vars(r)["animation"], r.URL.Query())
+}
+
+// getSVG serves GET /{animation}.svg, an animated SVG image. It takes the
+// frames parameters plus color, mode and loops.
+func getSVG(w http.ResponseWriter, r *http.Request) {
+	web.ServeSVG(w, mux.Vars(r)["animation"], r.URL.Query())
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"seal-ascii/web"
+	"seal-ascii/web/webtest"
+)
+
+func TestRoutes(t *testing.T) {
+	webtest.Register(t)
+
+	// httptest requests are for example.com
+	list := webtest.List("curl http://example.com/{animation}", "curl http://example.com/seal")
+	routes := append(webtest.Routes(t, list),
+		webtest.Route{Path: "/api/v1/animations/walrus/ws", Status: http.StatusNotFound, Want: web.Error{Error: `animation "walrus" not found`}},
+	)
+	webtest.CheckRoutes(t, newRouter(), "", routes)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"seal-ascii/negotiate"
+)
+
+// config holds the server settings. Each one can come from an environment
+// variable, which suits container deployments, and a command line flag
+// overrides it.
+type config struct {
+	Addr              string        // listen address
+	ReadHeaderTimeout time.Duration // time allowed to read request headers
+	IdleTimeout       time.Duration // keep-alive connections idle longer are closed
+	ShutdownTimeout   time.Duration // time streams get to finish on shutdown
+	TelnetAddr        string        // telnet listen address, empty to turn telnet off
+	SSHAddr           string        // SSH listen address, empty to turn SSH off
+	SSHHostKey        string        // SSH host key file, generated if missing
+	MenuTimeout       time.Duration // how long the telnet and SSH menu waits for a choice
+	AllowAgents       []string      // extra User-Agents that get the ANSI stream
+	DenyAgents        []string      // extra User-Agents that get the HTML page
+}
+
+// defaultConfig returns the settings used when nothing is configured
+func defaultConfig() config {
+	return config{
+		Addr:              ":8081",
+		ReadHeaderTimeout: 5 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		ShutdownTimeout:   10 * time.Second,
+		SSHHostKey:        "seal_ssh_host_key",
+		MenuTimeout:       2 * time.Minute,
+	}
+}
+
+// loadConfig reads the environment through getenv and then the flags in
+// args. PORT, as set by most hosting platforms, is honoured when SEAL_ADDR
+// isn't set.
+func loadConfig(args []string, getenv func(string) string) (config, error) {
+	cfg := defaultConfig()
+	if port := getenv("PORT"); port != "" {
+		cfg.Addr = ":" + port
+	}
+	if addr := getenv("SEAL_ADDR"); addr != "" {
+		cfg.Addr = addr
+	}
+	cfg.TelnetAddr = getenv("SEAL_TELNET_ADDR")
+	cfg.SSHAddr = getenv("SEAL_SSH_ADDR")
+	if key := getenv("SEAL_SSH_HOST_KEY"); key != "" {
+		cfg.SSHHostKey = key
+	}
+	cfg.AllowAgents = negotiate.ParseList(getenv("SEAL_ALLOW_AGENTS"))
+	cfg.DenyAgents = negotiate.ParseList(getenv("SEAL_DENY_AGENTS"))
+
+	durations := []struct {
+		env, flag, usage string
+		value            *time.Duration
+	}{
+		{"SEAL_READ_HEADER_TIMEOUT", "read-header-timeout", "time allowed to read request headers", &cfg.ReadHeaderTimeout},
+		{"SEAL_IDLE_TIMEOUT", "idle-timeout", "close keep-alive connections idle for this long", &cfg.IdleTimeout},
+		{"SEAL_SHUTDOWN_TIMEOUT", "shutdown-timeout", "time streams get to finish on shutdown", &cfg.ShutdownTimeout},
+		{"SEAL_MENU_TIMEOUT", "menu-timeout", "how long the telnet and SSH menu waits for a choice", &cfg.MenuTimeout},
+	}
+	for _, d := range durations {
+		value := getenv(d.env)
+		if value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(value)
+		if err != nil || v < 0 {
+			return cfg, fmt.Errorf("invalid %s %q", d.env, value)
+		}
+		*d.value = v
+	}
+
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.StringVar(&cfg.Addr, "addr", cfg.Addr, "listen address (SEAL_ADDR)")
+	fs.StringVar(&cfg.TelnetAddr, "telnet-addr", cfg.TelnetAddr, "telnet listen address, e.g. :2323 (SEAL_TELNET_ADDR)")
+	fs.StringVar(&cfg.SSHAddr, "ssh-addr", cfg.SSHAddr, "SSH listen address, e.g. :2222 (SEAL_SSH_ADDR)")
+	fs.StringVar(&cfg.SSHHostKey, "ssh-host-key", cfg.SSHHostKey, "SSH host key file, generated on first run (SEAL_SSH_HOST_KEY)")
+	for _, d := range durations {
+		fs.DurationVar(d.value, d.flag, *d.value, d.usage+" ("+d.env+")")
+	}
+	fs.Func("allow-agents", "comma separated User-Agent substrings that get the ANSI stream (SEAL_ALLOW_AGENTS)", listFlag(&cfg.AllowAgents))
+	fs.Func("deny-agents", "comma separated User-Agent substrings that get the HTML page (SEAL_DENY_AGENTS)", listFlag(&cfg.DenyAgents))
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// listFlag parses a comma separated list flag into list. The first use
+// replaces the value taken from the environment, repeating the flag adds to
+// it.
+func listFlag(list *[]string) func(string) error {
+	set := false
+	return func(value string) error {
+		if !set {
+			*list, set = nil, true
+		}
+		*list = append(*list, negotiate.ParseList(value)...)
+		return nil
+	}
+}
+
+// server builds the http.Server for cfg. There is no write timeout since
+// streams stay open indefinitely, each frame write sets its own deadline.
+func (cfg config) server(handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
+
+// url returns a base URL clients on this machine can use
+func (cfg config) url() string {
+	if strings.HasPrefix(cfg.Addr, ":") {
+		return "http://localhost" + cfg.Addr
+	}
+	return "http://" + cfg.Addr
+}
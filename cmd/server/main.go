@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"seal-ascii/animations"
+	"seal-ascii/negotiate"
+	"seal-ascii/web"
+)
+
+const (
+	// writeTimeout bounds how long a single frame write may block on a slow
+	// or vanished client
+	writeTimeout = 10 * time.Second
+
+	// goodbye is the last frame live streams get when the server shuts down
+	goodbye = "\n🦭 The seal is off to sleep, the server is restarting. Try again in a moment!\n"
+)
+
+var (
+	// closing is closed when the server starts shutting down, telling live
+	// streams to say goodbye and finish
+	closing = make(chan struct{})
+
+	// sessions counts open WebSocket, telnet and SSH connections, which
+	// Shutdown doesn't track
+	sessions sessionGroup
+
+	// negotiator picks each client's output, main adds the configured
+	// User-Agent lists
+	negotiator = negotiate.Default
+)
+
+func main() {
+	log.SetFlags(0)
+	cfg, err := loadConfig(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	negotiator = negotiate.New(cfg.AllowAgents, cfg.DenyAgents)
+	srv := cfg.server(newRouter())
+	srv.RegisterOnShutdown(func() { close(closing) })
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+
+	fmt.Printf("🦭 Seal ASCII Animation Server running on %s\n", cfg.url())
+	fmt.Printf("Try: curl %s\n", cfg.url())
+	fmt.Printf("Or visit: %s/list for available animations\n", cfg.url())
+
+	// Telnet and SSH serve the same animations to interactive terminals
+	var listeners []net.Listener
+	if cfg.TelnetAddr != "" {
+		ln, err := net.Listen("tcp", cfg.TelnetAddr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		listeners = append(listeners, ln)
+		go serveListener(ln, "telnet", func(c net.Conn) { serveTelnetConn(c, cfg.MenuTimeout) })
+		_, port, _ := net.SplitHostPort(ln.Addr().String())
+		fmt.Printf("Telnet: telnet localhost %s\n", port)
+	}
+	if cfg.SSHAddr != "" {
+		hostKey, err := loadHostKey(cfg.SSHHostKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ln, err := net.Listen("tcp", cfg.SSHAddr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		listeners = append(listeners, ln)
+		config := sshConfig(hostKey)
+		go serveListener(ln, "ssh", func(c net.Conn) { serveSSHConn(c, config, cfg.MenuTimeout) })
+		_, port, _ := net.SplitHostPort(ln.Addr().String())
+		fmt.Printf("SSH: ssh -p %s seal@localhost\n", port)
+	}
+
+	select {
+	case err := <-errc:
+		log.Fatal(err)
+	case <-ctx.Done():
+	}
+	stop() // a second signal kills the process straight away
+
+	// Streams get their goodbye frame and return, then Shutdown sees the
+	// connections go idle. Whatever is left at the deadline is cut off.
+	log.Printf("shutting down, draining streams for up to %s", cfg.ShutdownTimeout)
+	for _, ln := range listeners {
+		ln.Close()
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: %v, closing remaining connections", err)
+		srv.Close()
+	}
+
+	// WebSocket, telnet and SSH sessions say goodbye on their own, give them
+	// what is left of the deadline
+	drained := make(chan struct{})
+	go func() {
+		sessions.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-shutdownCtx.Done():
+		log.Printf("shutdown: closing remaining sessions")
+	}
+}
+
+// newRouter registers the server's routes. Fixed paths come first since
+// mux matches in registration order and /{animation} matches any of them.
+func newRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	// API endpoint for listing available animations
+	r.HandleFunc("/list", listAnimations).Methods("GET")
+
+	// Versioned JSON API
+	v1 := r.PathPrefix("/api/v1").Subrouter()
+	v1.HandleFunc("/animations", listAnimationsV1).Methods("GET")
+	v1.HandleFunc("/animations/{animation}", getAnimationV1).Methods("GET")
+	v1.HandleFunc("/animations/{animation}/frames", getFramesV1).Methods("GET")
+	v1.HandleFunc("/animations/{animation}/events", getEventsV1).Methods("GET")
+	v1.HandleFunc("/animations/{animation}/ws", streamSocket).Methods("GET")
+	v1.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		web.WriteError(w, http.StatusNotFound, "no such endpoint")
+	})
+
+	// Recordings and images, matched before the stream which would take the suffix as
+	// part of the name
+	r.HandleFunc("/{animation}.cast", getCast).Methods("GET")
+	r.HandleFunc("/{animation}.svg", getSVG).Methods("GET")
+
+	// Animation streaming endpoints
+	r.HandleFunc("/{animation}", streamAnimation).Methods("GET")
+	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		streamAnimation(w, r) // Default to seal animation
+	}).Methods("GET")
+	return r
+}
+
+func streamAnimation(w http.ResponseWriter, r *http.Request) {
+	// Pick the output from ?format=, the Accept header and the User-Agent
+	w.Header().Add("Vary", "Accept, User-Agent")
+	profile, err := negotiator.Negotiate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Get animation name from URL path
+	vars := mux.Vars(r)
+	animationName := vars["animation"]
+	if animationName == "" {
+		animationName = "seal" // Default animation
+	}
+
+	// Get animation from frame map
+	animation, exists := animations.FrameMap[animationName]
+	if !exists {
+		http.Error(w, fmt.Sprintf("Animation '%s' not found", animationName), http.StatusNotFound)
+		return
+	}
+	if profile == negotiate.JSON {
+		web.WriteJSON(w, http.StatusOK, animation.Describe(animationName))
+		return
+	}
+	if profile == negotiate.HTML {
+		// Browsers get a player that fetches the frames as JSON
+		web.ServePlayer(w, web.NewPlayer(animationName, animation, r.URL.Query(), "", "http://"+r.Host+"/"+animationName))
+		return
+	}
+
+	// Size, time and colour the frames for the client's terminal, leaving
+	// room for the cursor line
+	resolved, err := web.Resolve(animation, r.URL.Query(), r.Header, "", 1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Plain text clients get the first frame alone, without escape sequences
+	// or a footer
+	if profile == negotiate.Plain {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, resolved.Animation.GetFrame(0))
+		return
+	}
+
+	// Event stream clients get the frames as text, timed by the server
+	if profile == negotiate.SSE {
+		web.Events{
+			Animation:    resolved.Animation,
+			Playback:     resolved.Playback,
+			WriteTimeout: writeTimeout,
+			Closing:      closing,
+		}.ServeHTTP(w, r)
+		return
+	}
+
+	// Each frame stays up for its own duration until the playback ends,
+	// the client goes away or the server shuts down
+	web.Stream{
+		Animation:    resolved.Animation,
+		Frames:       resolved.Frames,
+		Playback:     resolved.Playback,
+		WriteTimeout: writeTimeout,
+		Closing:      closing,
+		Goodbye:      goodbye,
+	}.ServeHTTP(w, r)
+}
+
+// serveListener accepts connections on ln until it is closed, handling each
+// in its own goroutine. Sessions counts them so shutdown can wait.
+func serveListener(ln net.Listener, name string, handle func(net.Conn)) {
+	var backoff time.Duration
+	for {
+		conn, err := ln.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			// Out of file descriptors and the like, wait for it to pass
+			backoff = min(max(2*backoff, 5*time.Millisecond), time.Second)
+			log.Printf("%s: accept: %v, retrying in %s", name, err, backoff)
+			time.Sleep(backoff)
+			continue
+		}
+		backoff = 0
+
+		if !sessions.Add() {
+			conn.Close()
+			return
+		}
+		go func() {
+			defer sessions.Done()
+			handle(conn)
+		}()
+	}
+}
+
+// sessionGroup counts open sessions. Unlike a bare WaitGroup it turns new
+// sessions away once shutdown has started waiting, so Add never races Wait.
+type sessionGroup struct {
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	waiting bool
+}
+
+// Add counts a new session, reporting false if shutdown is already waiting
+func (g *sessionGroup) Add() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.waiting {
+		return false
+	}
+	g.wg.Add(1)
+	return true
+}
+
+// Done marks a session counted by Add as finished
+func (g *sessionGroup) Done() {
+	g.wg.Done()
+}
+
+// Wait waits for the sessions counted so far, refusing any more
+func (g *sessionGroup) Wait() {
+	g.mu.Lock()
+	g.waiting = true
+	g.mu.Unlock()
+	g.wg.Wait()
+}
+
+func listAnimations(w http.ResponseWriter, r *http.Request) {
+	web.ServeList(w, "curl http://"+r.Host+"/{animation}", "curl http://"+r.Host+"/seal")
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionGroup(t *testing.T) {
+	var g sessionGroup
+	if !g.Add() {
+		t.Fatal("Add() refused a session before Wait")
+	}
+
+	waited := make(chan struct{})
+	go func() {
+		g.Wait()
+		close(waited)
+	}()
+
+	// Once Wait has started no more sessions are counted
+	deadline := time.Now().Add(time.Second)
+	for g.Add() {
+		g.Done()
+		if time.Now().After(deadline) {
+			t.Fatal("Add() still counting sessions after Wait")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-waited:
+		t.Fatal("Wait returned with a session open")
+	default:
+	}
+
+	g.Done()
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Fatal("Wait didn't return once the session finished")
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"seal-ascii/animations"
+	"seal-ascii/render"
+	"seal-ascii/web"
+)
+
+const (
+	// pingInterval is how often idle sockets are pinged, a client that
+	// doesn't answer within pongTimeout is dropped
+	pingInterval = 30 * time.Second
+	pongTimeout  = 2 * pingInterval
+
+	// maxControlSize bounds a client control message
+	maxControlSize = 4096
+)
+
+// upgrader accepts WebSocket connections. The default origin check only
+// lets pages served by this host connect from a browser.
+var upgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 16 * 1024}
+
+// control is a message sent by a WebSocket client
+type control struct {
+	Type  string  `json:"type"`            // pause, resume, seek, speed, animation or resize
+	Frame int     `json:"frame,omitempty"` // seek target
+	Speed float64 `json:"speed,omitempty"` // playback speed, 1 is normal
+	Name  string  `json:"name,omitempty"`  // animation to switch to
+	Cols  int     `json:"cols,omitempty"`  // terminal size, 0 for no limit
+	Rows  int     `json:"rows,omitempty"`
+}
+
+// message is sent to a WebSocket client. Frames carry the same bytes as the
+// HTTP stream in Data.
+type message struct {
+	Type      string `json:"type"`  // frame, state, end, error or goodbye
+	Index     int    `json:"index"` // frame on screen
+	Data      string `json:"data,omitempty"`
+	Animation string `json:"animation,omitempty"`
+	Frames    int    `json:"frames,omitempty"`
+	Paused    bool   `json:"paused,omitempty"`
+	Speed     string `json:"speed,omitempty"`
+	Cols      string `json:"cols,omitempty"`
+	Rows      string `json:"rows,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// session is the playback state of one WebSocket viewer. Options live in
+// query, exactly as the HTTP stream reads them, and controls edit it.
+type session struct {
+	name   string
+	query  url.Values
+	header http.Header
+
+	animation *animations.FrameType
+	sequence  *animations.Sequence
+	stream    *render.Stream
+	index     int // frame on screen
+	paused    bool
+}
+
+// load resolves the session's animation and options into a stream, the way
+// streamAnimation does. A new animation restarts playback, otherwise the
+// sequence carries on from where it was.
+func (s *session) load(restart bool) error {
+	animation, exists := animations.FrameMap[s.name]
+	if !exists {
+		return fmt.Errorf("animation %q not found", s.name)
+	}
+	resolved, err := web.Resolve(animation, s.query, s.header, "", 1)
+	if err != nil {
+		return err
+	}
+	s.animation = resolved.Animation
+	s.stream = resolved.Frames
+	if restart || s.sequence == nil {
+		s.sequence = animations.NewSequence(resolved.Animation.GetLength(), resolved.Playback)
+		s.index = 0
+	}
+	return nil
+}
+
+// apply carries out a control message, returning whether the frame on
+// screen should be drawn again
+func (s *session) apply(c control) (bool, error) {
+	switch c.Type {
+	case "pause":
+		s.paused = true
+		return false, nil
+	case "resume":
+		s.paused = false
+		return false, nil
+	case "seek":
+		if c.Frame < 0 || c.Frame >= s.animation.GetLength() {
+			return false, fmt.Errorf("invalid frame %d", c.Frame)
+		}
+		s.sequence.Seek(c.Frame)
+		return true, nil
+	case "speed":
+		if c.Speed <= 0 {
+			return false, fmt.Errorf("invalid speed %v", c.Speed)
+		}
+		return s.reload(false, "speed", strconv.FormatFloat(c.Speed, 'g', -1, 64))
+	case "animation":
+		previous := s.name
+		s.name = c.Name
+		if err := s.load(true); err != nil {
+			s.name = previous
+			return false, err
+		}
+		return true, nil
+	case "resize":
+		if c.Cols < 0 || c.Rows < 0 {
+			return false, fmt.Errorf("invalid size %dx%d", c.Cols, c.Rows)
+		}
+		return s.reload(false, "cols", strconv.Itoa(c.Cols), "rows", strconv.Itoa(c.Rows))
+	case "":
+		return false, errors.New("invalid control message")
+	}
+	return false, fmt.Errorf("unknown control %q", c.Type)
+}
+
+// reload sets query keys and values in pairs and loads the result, putting
+// the old values back if they don't load
+func (s *session) reload(restart bool, pairs ...string) (bool, error) {
+	old := url.Values{}
+	for i := 0; i < len(pairs); i += 2 {
+		old[pairs[i]] = s.query[pairs[i]]
+		s.query.Set(pairs[i], pairs[i+1])
+	}
+	if err := s.load(restart); err != nil {
+		for key, values := range old {
+			s.query[key] = values
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// state describes the session to the client
+func (s *session) state() message {
+	return message{
+		Type:      "state",
+		Index:     s.index,
+		Animation: s.name,
+		Frames:    s.animation.GetLength(),
+		Paused:    s.paused,
+		Speed:     s.query.Get("speed"),
+		Cols:      s.query.Get("cols"),
+		Rows:      s.query.Get("rows"),
+	}
+}
+
+// streamSocket serves GET /api/v1/animations/{animation}/ws. It takes the
+// same query parameters as the HTTP stream, pushes each frame as a JSON
+// message and reads control messages from the client.
+func streamSocket(w http.ResponseWriter, r *http.Request) {
+	s := &session{name: mux.Vars(r)["animation"], query: r.URL.Query(), header: r.Header}
+	if err := s.load(true); err != nil {
+		status := http.StatusBadRequest
+		if _, exists := animations.FrameMap[s.name]; !exists {
+			status = http.StatusNotFound
+		}
+		web.WriteError(w, status, err.Error())
+		return
+	}
+
+	// Shutdown stops tracking the connection once the upgrade hijacks it, so
+	// the session is counted first, while shutdown is still waiting on it
+	counted := false
+	select {
+	case <-closing:
+	default:
+		counted = sessions.Add()
+	}
+	if !counted {
+		web.WriteError(w, http.StatusServiceUnavailable, "server is shutting down")
+		return
+	}
+	defer sessions.Done()
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // the upgrader has already answered
+	}
+	defer conn.Close()
+
+	send := func(m message) error {
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		return conn.WriteJSON(m)
+	}
+
+	// The reader hands controls over until the connection fails or closes
+	controls := make(chan control)
+	done := make(chan struct{})
+	conn.SetReadLimit(maxControlSize)
+	conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+	go func() {
+		defer close(done)
+		for {
+			_, p, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.SetReadDeadline(time.Now().Add(pongTimeout))
+			var c control
+			if json.Unmarshal(p, &c) != nil {
+				c = control{} // reported as an invalid control
+			}
+			select {
+			case controls <- c:
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	if err := send(s.state()); err != nil {
+		return
+	}
+
+	// draw advances the sequence and pushes the frame, scheduling the next
+	// one unless playback is paused. The connection stays open once a
+	// finite playback ends so the client can seek or switch animation.
+	draw := func() error {
+		index, ok := s.sequence.Next()
+		if !ok {
+			return send(message{Type: "end", Data: s.animation.GetPlayback().Outro})
+		}
+		s.index = index
+		if err := send(message{Type: "frame", Index: index, Data: string(s.stream.Render(index))}); err != nil {
+			return err
+		}
+		if !s.paused {
+			timer.Reset(s.animation.GetSleep(index))
+		}
+		return nil
+	}
+
+	for {
+		select {
+		case <-timer.C:
+			if err := draw(); err != nil {
+				return
+			}
+
+		case c := <-controls:
+			redraw, err := s.apply(c)
+			if err != nil {
+				if send(message{Type: "error", Index: s.index, Error: err.Error()}) != nil {
+					return
+				}
+				continue
+			}
+			timer.Stop()
+			switch {
+			case redraw:
+				// Seeks and new animations have moved the sequence, other
+				// changes draw the frame on screen again
+				if c.Type != "seek" && c.Type != "animation" {
+					s.sequence.Seek(s.index)
+				}
+				if err := draw(); err != nil {
+					return
+				}
+			case !s.paused:
+				timer.Reset(s.animation.GetSleep(s.index))
+			}
+			if send(s.state()) != nil {
+				return
+			}
+
+		case <-ping.C:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)) != nil {
+				return
+			}
+
+		case <-closing:
+			send(message{Type: "goodbye", Data: goodbye})
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(writeTimeout))
+			return
+
+		case <-done:
+			return
+		}
+	}
+}
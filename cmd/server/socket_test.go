@@ -0,0 +1,173 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"seal-ascii/web/webtest"
+)
+
+// dialSocket connects to the WebSocket endpoint of animation on a test server
+func dialSocket(t *testing.T, animation, query string) *websocket.Conn {
+	t.Helper()
+	srv := httptest.NewServer(newRouter())
+	t.Cleanup(srv.Close)
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/v1/animations/" + animation + "/ws" + query
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial %s: %v", url, err)
+	}
+	resp.Body.Close()
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// await reads messages until one of type kind arrives, skipping the frames
+// that keep coming in between
+func await(t *testing.T, conn *websocket.Conn, kind string) message {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var m message
+		if err := conn.ReadJSON(&m); err != nil {
+			t.Fatalf("waiting for %s: %v", kind, err)
+		}
+		if m.Type == kind {
+			return m
+		}
+		if m.Type == "error" {
+			t.Fatalf("waiting for %s: got error %q", kind, m.Error)
+		}
+	}
+}
+
+// send writes a raw control message
+func send(t *testing.T, conn *websocket.Conn, raw string) {
+	t.Helper()
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(raw)); err != nil {
+		t.Fatalf("send %s: %v", raw, err)
+	}
+}
+
+func TestSocketControls(t *testing.T) {
+	webtest.Register(t)
+	conn := dialSocket(t, "dots", "")
+
+	state := await(t, conn, "state")
+	if state.Animation != "dots" || state.Frames != 4 || state.Paused {
+		t.Fatalf("initial state = %+v", state)
+	}
+	if frame := await(t, conn, "frame"); frame.Data == "" {
+		t.Fatalf("first frame has no data")
+	}
+
+	send(t, conn, `{"type":"pause"}`)
+	if state := await(t, conn, "state"); !state.Paused {
+		t.Fatalf("state after pause = %+v", state)
+	}
+
+	// Paused playback only draws when asked to
+	send(t, conn, `{"type":"seek","frame":2}`)
+	if frame := await(t, conn, "frame"); frame.Index != 2 {
+		t.Errorf("seek drew frame %d, want 2", frame.Index)
+	}
+	if state := await(t, conn, "state"); state.Index != 2 || !state.Paused {
+		t.Errorf("state after seek = %+v", state)
+	}
+
+	send(t, conn, `{"type":"speed","speed":2}`)
+	if state := await(t, conn, "state"); state.Speed != "2" {
+		t.Errorf("state after speed = %+v", state)
+	}
+
+	send(t, conn, `{"type":"resize","cols":40,"rows":10}`)
+	if state := await(t, conn, "state"); state.Cols != "40" || state.Rows != "10" {
+		t.Errorf("state after resize = %+v", state)
+	}
+
+	send(t, conn, `{"type":"animation","name":"blink"}`)
+	if frame := await(t, conn, "frame"); frame.Index != 0 {
+		t.Errorf("new animation drew frame %d, want 0", frame.Index)
+	}
+	if state := await(t, conn, "state"); state.Animation != "blink" || state.Frames != 2 {
+		t.Errorf("state after animation = %+v", state)
+	}
+
+	send(t, conn, `{"type":"resume"}`)
+	if state := await(t, conn, "state"); state.Paused {
+		t.Errorf("state after resume = %+v", state)
+	}
+	await(t, conn, "frame")
+}
+
+func TestSocketControlErrors(t *testing.T) {
+	webtest.Register(t)
+
+	tests := []struct {
+		control string
+		err     string
+	}{
+		{`{"type":"jump"}`, `unknown control "jump"`},
+		{`not json`, "invalid control message"},
+		{`{"type":"seek","frame":4}`, "invalid frame 4"},
+		{`{"type":"seek","frame":-1}`, "invalid frame -1"},
+		{`{"type":"speed","speed":-1}`, "invalid speed -1"},
+		{`{"type":"resize","cols":-1}`, "invalid size -1x0"},
+		{`{"type":"animation","name":"walrus"}`, `animation "walrus" not found`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.control, func(t *testing.T) {
+			conn := dialSocket(t, "dots", "")
+			await(t, conn, "state")
+			send(t, conn, tt.control)
+
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			for {
+				var m message
+				if err := conn.ReadJSON(&m); err != nil {
+					t.Fatalf("waiting for error: %v", err)
+				}
+				if m.Type == "state" {
+					t.Fatalf("control was accepted: %+v", m)
+				}
+				if m.Type == "error" {
+					if m.Error != tt.err {
+						t.Errorf("error = %q, want %q", m.Error, tt.err)
+					}
+					return
+				}
+			}
+		})
+	}
+
+	// The session carries on after an error
+	conn := dialSocket(t, "dots", "")
+	await(t, conn, "state")
+	send(t, conn, `{"type":"jump"}`)
+	await(t, conn, "error")
+	send(t, conn, `{"type":"pause"}`)
+	if state := await(t, conn, "state"); !state.Paused || state.Animation != "dots" {
+		t.Errorf("state after error = %+v", state)
+	}
+}
+
+func TestSocketUnknownAnimation(t *testing.T) {
+	srv := httptest.NewServer(newRouter())
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/v1/animations/walrus/ws"
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil {
+		t.Fatal("dial succeeded for an unknown animation")
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("response = %v, want 404", resp)
+	}
+	resp.Body.Close()
+}
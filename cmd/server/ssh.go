@@ -0,0 +1,241 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"seal-ascii/animations"
+	"seal-ascii/terminal"
+)
+
+// handshakeTimeout bounds the SSH handshake, authentication included
+const handshakeTimeout = 10 * time.Second
+
+// loadHostKey reads the SSH host key at path, generating and saving an
+// ed25519 key there on first run so clients see the same key every time
+func loadHostKey(path string) (ssh.Signer, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		block, err := ssh.MarshalPrivateKey(key, "silly_seal host key")
+		if err != nil {
+			return nil, err
+		}
+		data = pem.EncodeToMemory(block)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			return nil, err
+		}
+		log.Printf("ssh: generated host key %s", path)
+	} else if err != nil {
+		return nil, err
+	}
+	return ssh.ParsePrivateKey(data)
+}
+
+// sshConfig builds the server configuration. Anyone gets in: clients trying
+// no authentication, a password or any key are all accepted.
+func sshConfig(hostKey ssh.Signer) *ssh.ServerConfig {
+	config := &ssh.ServerConfig{
+		NoClientAuth: true,
+		PasswordCallback: func(ssh.ConnMetadata, []byte) (*ssh.Permissions, error) {
+			return nil, nil
+		},
+		PublicKeyCallback: func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) {
+			return nil, nil
+		},
+		ServerVersion: "SSH-2.0-silly_seal",
+	}
+	config.AddHostKey(hostKey)
+	return config
+}
+
+// serveSSHConn runs one SSH client's connection. Its first session gets the
+// animations and the connection closes when that session ends.
+func serveSSHConn(c net.Conn, config *ssh.ServerConfig, menuTimeout time.Duration) {
+	defer c.Close()
+	c.SetDeadline(time.Now().Add(handshakeTimeout))
+	conn, channels, requests, err := ssh.NewServerConn(c, config)
+	if err != nil {
+		return
+	}
+	c.SetDeadline(time.Time{})
+	defer conn.Close()
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only sessions are supported")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			serveSSHSession(conn, channel, requests, menuTimeout)
+			conn.Close()
+		}()
+	}
+}
+
+// ptyRequest is the payload of a "pty-req" request, RFC 4254 section 6.2
+type ptyRequest struct {
+	Term          string
+	Cols, Rows    uint32
+	Width, Height uint32
+	Modes         string
+}
+
+// windowChange is the payload of a "window-change" request
+type windowChange struct {
+	Cols, Rows    uint32
+	Width, Height uint32
+}
+
+// execRequest is the payload of an "exec" request
+type execRequest struct {
+	Command string
+}
+
+// serveSSHSession plays animations on one session channel. The user name
+// or the command, as in ssh seal@host or ssh host seal, picks the animation
+// to start with, anything else starts at the menu.
+func serveSSHSession(conn *ssh.ServerConn, channel ssh.Channel, requests <-chan *ssh.Request, menuTimeout time.Duration) {
+	defer channel.Close()
+
+	// Requests arrive until the channel closes. The terminal and its size
+	// come before the shell starts, later sizes go to the session. pty,
+	// hasPTY and command are only written before started is closed.
+	done := make(chan struct{})
+	defer close(done)
+	started := make(chan struct{})
+	sizes := make(chan terminal.Size)
+	var pty ptyRequest
+	var hasPTY bool
+	var command string
+	go func() {
+		running := false
+		for req := range requests {
+			switch req.Type {
+			case "pty-req":
+				ok := !running && ssh.Unmarshal(req.Payload, &pty) == nil
+				if ok {
+					hasPTY = true
+				}
+				req.Reply(ok, nil)
+			case "window-change":
+				var change windowChange
+				if ssh.Unmarshal(req.Payload, &change) != nil {
+					continue
+				}
+				if !running {
+					pty.Cols, pty.Rows = change.Cols, change.Rows
+					continue
+				}
+				select {
+				case sizes <- terminal.Size{Cols: int(change.Cols), Rows: int(change.Rows)}:
+				case <-done:
+				}
+			case "shell", "exec":
+				var exec execRequest
+				if running || req.Type == "exec" && ssh.Unmarshal(req.Payload, &exec) != nil {
+					req.Reply(false, nil)
+					continue
+				}
+				command = exec.Command
+				running = true
+				req.Reply(true, nil)
+				close(started)
+			default:
+				req.Reply(false, nil) // env, x11-req, agent forwarding...
+			}
+		}
+	}()
+
+	// A zero menu timeout waits for the shell forever
+	var timeout <-chan time.Time
+	if menuTimeout > 0 {
+		timer := time.NewTimer(menuTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-started:
+	case <-timeout:
+		return
+	case <-closing:
+		return
+	}
+
+	keys := make(chan byte)
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 64)
+		for {
+			n, err := channel.Read(buf)
+			for _, b := range buf[:n] {
+				select {
+				case keys <- b:
+				case <-done:
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	name := command
+	if _, ok := animations.FrameMap[name]; !ok {
+		name = conn.User()
+	}
+	if _, ok := animations.FrameMap[name]; !ok {
+		name = ""
+	}
+	session := terminal.Session{
+		Out:     terminal.CRLF(timeoutWriter{channel, writeTimeout, conn.Close}),
+		Keys:    keys,
+		Sizes:   sizes,
+		Size:    terminal.Size{Cols: int(pty.Cols), Rows: int(pty.Rows)},
+		Term:    pty.Term,
+		Echo:    hasPTY,
+		Idle:    menuTimeout,
+		Closing: closing,
+		Goodbye: goodbye,
+	}
+	session.Run(name)
+	channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+}
+
+// timeoutWriter closes the connection when a write blocks for longer than
+// timeout, the only way to give up on an SSH channel whose client stopped
+// reading
+type timeoutWriter struct {
+	w       io.Writer
+	timeout time.Duration
+	close   func() error
+}
+
+func (t timeoutWriter) Write(p []byte) (int, error) {
+	timer := time.AfterFunc(t.timeout, func() { t.close() })
+	defer timer.Stop()
+	return t.w.Write(p)
+}
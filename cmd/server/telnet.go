@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net"
+	"time"
+
+	"seal-ascii/telnet"
+	"seal-ascii/terminal"
+)
+
+// negotiateTimeout is how long a telnet client gets to report its window
+// size and terminal type
+const negotiateTimeout = 2 * time.Second
+
+// serveTelnetConn runs one telnet client's session
+func serveTelnetConn(c net.Conn, menuTimeout time.Duration) {
+	conn := telnet.NewConn(c, writeTimeout)
+	defer conn.Close()
+	if err := conn.Negotiate(negotiateTimeout); err != nil {
+		return
+	}
+
+	// Hand keys and window sizes to the session until it ends
+	done := make(chan struct{})
+	defer close(done)
+	keys := make(chan byte)
+	sizes := make(chan terminal.Size)
+	conn.OnResize(func(cols, rows int) {
+		select {
+		case sizes <- terminal.Size{Cols: cols, Rows: rows}:
+		case <-done:
+		}
+	})
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 64)
+		for {
+			n, err := conn.Read(buf)
+			for _, b := range buf[:n] {
+				select {
+				case keys <- b:
+				case <-done:
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	cols, rows := conn.Size()
+	session := terminal.Session{
+		Out:     conn,
+		Keys:    keys,
+		Sizes:   sizes,
+		Size:    terminal.Size{Cols: cols, Rows: rows},
+		Term:    conn.Term(),
+		Echo:    conn.Echo(),
+		Idle:    menuTimeout,
+		Closing: closing,
+		Goodbye: goodbye,
+	}
+	session.Run("")
+}
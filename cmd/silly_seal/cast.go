@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"seal-ascii/animations"
+	"seal-ascii/asciicast"
+	"seal-ascii/convert"
+	"seal-ascii/render"
+)
+
+// cast runs the cast command: the animation named in args, or the seal,
+// written as an asciicast v2 recording
+func cast(args []string) error {
+	fs := flag.NewFlagSet("cast", flag.ContinueOnError)
+	color := fs.String("color", "truecolor", "colour mode: none, 16, 256 or truecolor")
+	charset := fs.String("charset", "", "glyphs: braille, ascii, half, quadrant or sextant (default braille)")
+	mode := fs.String("mode", "", "playback mode: loop, once, reverse or pingpong (default from the animation)")
+	loops := fs.String("loops", "", "passes to record, an endless playback is recorded once")
+	out := fs.String("o", "", "file to write (default standard output)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: silly_seal cast [flags] [animation]")
+		fmt.Fprintln(fs.Output(), "\nflags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errors.New("cast takes at most one animation")
+	}
+
+	name := fs.Arg(0)
+	if name == "" {
+		name = "seal"
+	}
+	animation, ok := animations.FrameMap[name]
+	if !ok {
+		return fmt.Errorf("no animation %q, try one of %v", name, animations.Names())
+	}
+	glyphs, err := convert.ParseCharset(*charset)
+	if err != nil {
+		return err
+	}
+	animation = animation.Glyphs(glyphs)
+	colorMode, err := render.ParseColor(*color, "", "")
+	if err != nil {
+		return err
+	}
+	playback, err := animation.GetPlayback().Override(*mode, *loops)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+	err = asciicast.Encode(bw, animation, asciicast.Options{
+		Title:     name,
+		Color:     colorMode,
+		Playback:  playback,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// importCast runs the import command: the recording named in args replayed
+// into an asset directory the server loads as a new animation
+func importCast(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	out := fs.String("out", "", "asset directory to create, e.g. animations/assets/<name>")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: silly_seal import -out dir recording.cast")
+		fmt.Fprintln(fs.Output(), "\nflags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() != 1 || *out == "" {
+		fs.Usage()
+		return errors.New("import takes one recording and an -out directory")
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	animation, _, err := asciicast.Decode(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+	files, err := animation.Assets()
+	if err != nil {
+		return err
+	}
+
+	// Refuse to mix the frames into an existing animation
+	if entries, err := os.ReadDir(*out); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s already exists and is not empty", *out)
+	}
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		return err
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(*out, name), data, 0o644); err != nil {
+			return err
+		}
+	}
+	width, height := animation.Size()
+	fmt.Fprintf(os.Stderr, "silly_seal: wrote %d frames (%dx%d %s) to %s\n",
+		animation.GetLength(), width, height, animation.GetCharset(), *out)
+	return nil
+}
@@ -0,0 +1,64 @@
+// Command silly_seal plays the animations without a server.
+//
+//	silly_seal play [flags] [animation]
+//
+// plays an animation in the current terminal.
+//
+//	silly_seal cast [flags] [animation]
+//
+// writes an animation as an asciicast v2 recording,
+//
+//	silly_seal svg [flags] [animation]
+//
+// writes it as an animated SVG, and
+//
+//	silly_seal import -out dir recording.cast
+//
+// turns a recording into an animation asset directory.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// usage is printed for a missing or unknown command
+const usage = `usage: silly_seal <command> [flags] [arguments]
+
+commands:
+  play [animation]   play an animation in this terminal (default seal)
+  cast [animation]   write an animation as an asciicast v2 recording
+  svg [animation]    write an animation as an animated SVG image
+  import file.cast   turn an asciicast recording into animation assets
+
+Run silly_seal <command> -h for the command's flags.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "play":
+		err = play(os.Args[2:])
+	case "cast":
+		err = cast(os.Args[2:])
+	case "svg":
+		err = image(os.Args[2:])
+	case "import":
+		err = importCast(os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "silly_seal: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "silly_seal:", err)
+		os.Exit(1)
+	}
+}
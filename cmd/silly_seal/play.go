@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/term"
+
+	"seal-ascii/animations"
+	"seal-ascii/render"
+	"seal-ascii/terminal"
+)
+
+const (
+	altScreen  = "\033[?1049h" // switch to the alternate screen buffer
+	mainScreen = "\033[?1049l" // and back, restoring what was there
+)
+
+// play runs the play command: the animation named in args, or the seal,
+// full screen in the current terminal until q, Ctrl+C or a signal
+func play(args []string) error {
+	fs := flag.NewFlagSet("play", flag.ContinueOnError)
+	color := fs.String("color", "", "colour mode: none, 16, 256 or truecolor (default from COLORTERM and TERM)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: silly_seal play [flags] [animation]")
+		fmt.Fprintln(fs.Output(), "\nkeys: space pause, +/- speed, ←/→ animation, m menu, q quit")
+		fmt.Fprintln(fs.Output(), "\nflags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errors.New("play takes at most one animation")
+	}
+
+	name := fs.Arg(0)
+	if name == "" {
+		name = "seal"
+	}
+	if _, ok := animations.FrameMap[name]; !ok {
+		return fmt.Errorf("no animation %q, try one of %v", name, animations.Names())
+	}
+	if _, err := render.ParseColor(*color, "", ""); err != nil {
+		return err
+	}
+
+	out := int(os.Stdout.Fd())
+	if !term.IsTerminal(out) {
+		return errors.New("play needs a terminal on standard output")
+	}
+	cols, rows, err := term.GetSize(out)
+	if err != nil {
+		return err
+	}
+
+	// Raw mode delivers each key as it is pressed, Ctrl+C included, so the
+	// session echoes and translates newlines itself. The terminal is put
+	// back however play ends.
+	in := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(in, state)
+	os.Stdout.WriteString(altScreen)
+	defer os.Stdout.WriteString(mainScreen)
+
+	// Signals end the session the way a server shutdown does
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer stop()
+
+	keys := make(chan byte)
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 64)
+		for {
+			n, err := os.Stdin.Read(buf)
+			for _, b := range buf[:n] {
+				keys <- b
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	session := terminal.Session{
+		Out:       terminal.CRLF(os.Stdout),
+		Keys:      keys,
+		Sizes:     watchSize(ctx, out),
+		Size:      terminal.Size{Cols: cols, Rows: rows},
+		Term:      os.Getenv("TERM"),
+		ColorTerm: os.Getenv("COLORTERM"),
+		Color:     *color,
+		Echo:      true,
+		Closing:   ctx.Done(),
+	}
+	return session.Run(name)
+}
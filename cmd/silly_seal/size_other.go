@@ -0,0 +1,44 @@
+//go:build !unix
+
+package main
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/term"
+
+	"seal-ascii/terminal"
+)
+
+// pollInterval is how often the size is checked where there is no SIGWINCH
+const pollInterval = 250 * time.Millisecond
+
+// watchSize reports the size of terminal fd whenever it changes, polling
+// for it until ctx is done
+func watchSize(ctx context.Context, fd int) <-chan terminal.Size {
+	sizes := make(chan terminal.Size)
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		cols, rows, _ := term.GetSize(fd)
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+			c, r, err := term.GetSize(fd)
+			if err != nil || c == cols && r == rows {
+				continue
+			}
+			cols, rows = c, r
+			select {
+			case sizes <- terminal.Size{Cols: cols, Rows: rows}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return sizes
+}
@@ -0,0 +1,42 @@
+//go:build unix
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/term"
+
+	"seal-ascii/terminal"
+)
+
+// watchSize reports the size of terminal fd each time SIGWINCH says it
+// changed, until ctx is done
+func watchSize(ctx context.Context, fd int) <-chan terminal.Size {
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	sizes := make(chan terminal.Size)
+	go func() {
+		defer signal.Stop(winch)
+		for {
+			select {
+			case <-winch:
+			case <-ctx.Done():
+				return
+			}
+			cols, rows, err := term.GetSize(fd)
+			if err != nil {
+				continue
+			}
+			select {
+			case sizes <- terminal.Size{Cols: cols, Rows: rows}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return sizes
+}
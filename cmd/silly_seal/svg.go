@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"seal-ascii/animations"
+	"seal-ascii/convert"
+	"seal-ascii/render"
+	"seal-ascii/svg"
+)
+
+// image runs the svg command: the animation named in args, or the seal,
+// written as an animated SVG
+func image(args []string) error {
+	fs := flag.NewFlagSet("svg", flag.ContinueOnError)
+	color := fs.String("color", "", "any colour mode (16, 256 or truecolor) uses the frames' colours")
+	charset := fs.String("charset", "", "glyphs: braille, ascii, half, quadrant or sextant (default braille)")
+	mode := fs.String("mode", "", "playback mode: loop, once, reverse or pingpong (default from the animation)")
+	loops := fs.String("loops", "", "passes to play before stopping on the last frame (default from the animation)")
+	fontSize := fs.Int("font-size", svg.DefaultFontSize, "font size in pixels")
+	maxSize := fs.Int("max-size", svg.DefaultMaxSize, "largest image in bytes, frames are dropped to fit (0 for no limit)")
+	out := fs.String("o", "", "file to write (default standard output)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: silly_seal svg [flags] [animation]")
+		fmt.Fprintln(fs.Output(), "\nflags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errors.New("svg takes at most one animation")
+	}
+
+	name := fs.Arg(0)
+	if name == "" {
+		name = "seal"
+	}
+	animation, ok := animations.FrameMap[name]
+	if !ok {
+		return fmt.Errorf("no animation %q, try one of %v", name, animations.Names())
+	}
+	glyphs, err := convert.ParseCharset(*charset)
+	if err != nil {
+		return err
+	}
+	animation = animation.Glyphs(glyphs)
+	colorMode, err := render.ParseColor(*color, "", "")
+	if err != nil {
+		return err
+	}
+	playback, err := animation.GetPlayback().Override(*mode, *loops)
+	if err != nil {
+		return err
+	}
+	if *fontSize < 1 {
+		return fmt.Errorf("invalid font size %d", *fontSize)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+	err = svg.Encode(bw, animation, svg.Options{
+		Title:    name,
+		Color:    colorMode != render.NoColor,
+		Playback: playback,
+		FontSize: *fontSize,
+		MaxSize:  *maxSize,
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,39 @@
+package convert
+
+import "strings"
+
+// BrailleBlank is the empty braille cell used for padding
+const BrailleBlank = '⠀'
+
+// brailleBits maps a dot position within a 2x4 cell to its bit in the
+// Unicode braille block, indexed by [row][column]
+var brailleBits = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// braille packs a (cols*2) x (rows*4) dot bitmap into braille text, one line
+// per row with a trailing newline, matching the layout of the seal frames
+func braille(dots []bool, cols, rows int) string {
+	w := cols * 2
+	var sb strings.Builder
+	sb.Grow(rows * (cols*3 + 1))
+
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			cell := BrailleBlank
+			for dy := 0; dy < 4; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					if dots[(row*4+dy)*w+col*2+dx] {
+						cell |= brailleBits[dy][dx]
+					}
+				}
+			}
+			sb.WriteRune(cell)
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
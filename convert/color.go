@@ -0,0 +1,157 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// ImageColors returns one colour per braille cell of the frame Image would
+// produce for img. The dot pattern already carries brightness, so each
+// colour is the brightness weighted hue of its cell scaled to full
+// intensity.
+func ImageColors(img image.Image, opts Options) [][]color.RGBA {
+	b := img.Bounds()
+	cols, rows := opts.size(b)
+
+	colors := make([][]color.RGBA, rows)
+	for row := range colors {
+		colors[row] = make([]color.RGBA, cols)
+		y0 := b.Min.Y + row*b.Dy()/rows
+		y1 := max(b.Min.Y+(row+1)*b.Dy()/rows, y0+1)
+		for col := range colors[row] {
+			x0 := b.Min.X + col*b.Dx()/cols
+			x1 := max(b.Min.X+(col+1)*b.Dx()/cols, x0+1)
+
+			var r, g, bl, weight float64
+			for y := y0; y < y1 && y < b.Max.Y; y++ {
+				for x := x0; x < x1 && x < b.Max.X; x++ {
+					w, a := luminance(img, x, y)
+					if opts.Invert {
+						w = 255*a - w
+					}
+					pr, pg, pb, _ := img.At(x, y).RGBA()
+					r += w * float64(pr)
+					g += w * float64(pg)
+					bl += w * float64(pb)
+					weight += w
+				}
+			}
+			if weight > 0 {
+				r, g, bl = r/weight, g/weight, bl/weight
+			}
+			colors[row][col] = saturate(r, g, bl)
+		}
+	}
+	return colors
+}
+
+// colorStep is the channel resolution colours are rounded to, which hides
+// sampling noise and keeps runs of similar cells identical
+const colorStep = 16
+
+// saturate scales a 16-bit colour so its brightest channel is at full
+// intensity, black becomes white
+func saturate(r, g, b float64) color.RGBA {
+	peak := max(r, g, b)
+	if peak <= 0 {
+		return color.RGBA{0xff, 0xff, 0xff, 0xff}
+	}
+	scale := 255 / peak
+	return color.RGBA{quantize(r * scale), quantize(g * scale), quantize(b * scale), 0xff}
+}
+
+// quantize rounds a 0-255 channel value to the nearest colorStep, keeping
+// full intensity reachable
+func quantize(v float64) uint8 {
+	q := int(v/colorStep+0.5) * colorStep
+	return uint8(min(q, 255))
+}
+
+// RescaleColors box-filters a colour grid to cols x rows cells
+func RescaleColors(colors [][]color.RGBA, cols, rows int) [][]color.RGBA {
+	var width int
+	for _, line := range colors {
+		width = max(width, len(line))
+	}
+	if cols <= 0 || rows <= 0 || width == 0 {
+		return colors
+	}
+
+	out := make([][]color.RGBA, rows)
+	for row := range out {
+		out[row] = make([]color.RGBA, cols)
+		y0, y1 := span(row, rows, len(colors))
+		for col := range out[row] {
+			x0, x1 := span(col, cols, width)
+
+			var r, g, b, n int
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1 && x < len(colors[y]); x++ {
+					c := colors[y][x]
+					r, g, b, n = r+int(c.R), g+int(c.G), b+int(c.B), n+1
+				}
+			}
+			if n > 0 {
+				out[row][col] = color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), 0xff}
+			}
+		}
+	}
+	return out
+}
+
+// FormatColors encodes a colour grid as text, one line per row of
+// whitespace separated RRGGBB cells. Repeated cells are written once with a
+// *count suffix.
+func FormatColors(colors [][]color.RGBA) []byte {
+	var buf bytes.Buffer
+	for _, line := range colors {
+		for i := 0; i < len(line); {
+			run := 1
+			for i+run < len(line) && line[i+run] == line[i] {
+				run++
+			}
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			fmt.Fprintf(&buf, "%02x%02x%02x", line[i].R, line[i].G, line[i].B)
+			if run > 1 {
+				fmt.Fprintf(&buf, "*%d", run)
+			}
+			i += run
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// ParseColors decodes the text written by FormatColors
+func ParseColors(data []byte) ([][]color.RGBA, error) {
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	colors := make([][]color.RGBA, len(lines))
+	for row, line := range lines {
+		for _, token := range strings.Fields(line) {
+			hex, count, repeated := strings.Cut(token, "*")
+			run := 1
+			if repeated {
+				n, err := strconv.Atoi(count)
+				if err != nil || n < 1 {
+					return nil, fmt.Errorf("row %d: bad count in %q", row+1, token)
+				}
+				run = n
+			}
+			v, err := strconv.ParseUint(hex, 16, 32)
+			if err != nil || len(hex) != 6 {
+				return nil, fmt.Errorf("row %d: bad colour %q", row+1, token)
+			}
+			c := color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}
+			for ; run > 0; run-- {
+				colors[row] = append(colors[row], c)
+			}
+		}
+	}
+	return colors, nil
+}
@@ -0,0 +1,152 @@
+// Package convert turns raster images into braille text frames that can be
+// registered as animations.
+package convert
+
+import (
+	"fmt"
+	"image"
+	_ "image/png" // register PNG decoder
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultWidth matches the column count ascii-image-converter picked for the
+// original seal frames
+const DefaultWidth = 149
+
+// DefaultThreshold is the brightness at or above which a dot is raised
+const DefaultThreshold = 128
+
+// DefaultBlackPoint and DefaultGamma match the tone of the original seal
+// frames: the near black backdrop stays blank and the body comes out about
+// half filled, so its outline shows
+const (
+	DefaultBlackPoint = 48
+	DefaultGamma      = 2
+)
+
+// Options controls how an image is mapped to braille cells
+type Options struct {
+	Width     int    // output width in braille cells, 0 means DefaultWidth
+	Height    int    // output height in braille cells, 0 keeps the aspect ratio
+	Threshold uint8  // brightness cut-off for raising a dot
+	Dither    Dither // dithering applied before thresholding
+	Invert    bool   // raise dots for dark pixels instead of bright ones
+
+	// BlackPoint is the brightness, after Invert, at or below which a pixel
+	// is background: it never raises a dot and dithering leaves it alone.
+	// Mostly transparent pixels are always background. The brightness above
+	// it is stretched back to the full range and raised to Gamma, 0 means 1.
+	BlackPoint uint8
+	Gamma      float64
+}
+
+// DefaultOptions returns the settings used to generate the seal frames
+func DefaultOptions() Options {
+	return Options{
+		Width:     DefaultWidth,
+		Threshold: DefaultThreshold,
+		Dither:    FloydSteinberg,
+
+		BlackPoint: DefaultBlackPoint,
+		Gamma:      DefaultGamma,
+	}
+}
+
+// size resolves the output dimensions in braille cells for an image
+func (o Options) size(bounds image.Rectangle) (int, int) {
+	width := o.Width
+	if width <= 0 {
+		width = DefaultWidth
+	}
+	height := o.Height
+	if height <= 0 && bounds.Dx() > 0 {
+		// Terminal cells are roughly twice as tall as they are wide
+		height = int(float64(width) * float64(bounds.Dy()) / float64(bounds.Dx()) / 2)
+	}
+	if height <= 0 {
+		height = 1
+	}
+	return width, height
+}
+
+// Image converts a decoded image into a single frame
+func Image(img image.Image, opts Options) string {
+	cols, rows := opts.size(img.Bounds())
+	gray := sample(img, cols*2, rows*4)
+	if opts.Invert {
+		gray.invert()
+	}
+	gamma := opts.Gamma
+	if gamma <= 0 {
+		gamma = 1
+	}
+	gray.levels(opts.BlackPoint, gamma)
+	dots := opts.Dither.apply(gray, opts.Threshold)
+	return braille(dots, cols, rows)
+}
+
+// Decode reads the image at path
+func Decode(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return img, nil
+}
+
+// File decodes the image at path and converts it into a single frame
+func File(path string, opts Options) (string, error) {
+	img, err := Decode(path)
+	if err != nil {
+		return "", err
+	}
+	return Image(img, opts), nil
+}
+
+// Dir converts every PNG in dir, ordered by file name, into a frame slice
+func Dir(dir string, opts Options) ([]string, error) {
+	paths, err := Files(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make([]string, 0, len(paths))
+	for _, path := range paths {
+		frame, err := File(path, opts)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+// Files lists the PNG files in dir sorted by name
+func Files(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".png") {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, entry.Name()))
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no PNG files in %s", dir)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
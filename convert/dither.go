@@ -0,0 +1,85 @@
+package convert
+
+// Dither selects how grey levels are spread across dots before thresholding
+type Dither int
+
+const (
+	// NoDither applies the threshold to each dot independently
+	NoDither Dither = iota
+	// FloydSteinberg diffuses quantisation error to neighbouring dots
+	FloydSteinberg
+	// Ordered applies a 4x4 Bayer matrix around the threshold
+	Ordered
+)
+
+// bayer4 is the classic 4x4 ordered dither matrix
+var bayer4 = [4][4]float64{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// ParseDither maps a flag value to a Dither mode
+func ParseDither(name string) (Dither, bool) {
+	switch name {
+	case "none", "":
+		return NoDither, true
+	case "floyd-steinberg", "fs":
+		return FloydSteinberg, true
+	case "ordered", "bayer":
+		return Ordered, true
+	}
+	return NoDither, false
+}
+
+// String returns the flag name of the dither mode
+func (d Dither) String() string {
+	switch d {
+	case FloydSteinberg:
+		return "floyd-steinberg"
+	case Ordered:
+		return "ordered"
+	}
+	return "none"
+}
+
+// apply reduces g to a bitmap of raised dots, background pixels staying flat
+func (d Dither) apply(g *grayImage, threshold uint8) []bool {
+	t := float64(threshold)
+	dots := make([]bool, g.w*g.h)
+
+	switch d {
+	case FloydSteinberg:
+		for y := 0; y < g.h; y++ {
+			for x := 0; x < g.w; x++ {
+				if g.bg[y*g.w+x] {
+					continue
+				}
+				old := g.at(x, y)
+				var v float64
+				if old >= t {
+					v = 255
+					dots[y*g.w+x] = true
+				}
+				e := old - v
+				g.add(x+1, y, e*7/16)
+				g.add(x-1, y+1, e*3/16)
+				g.add(x, y+1, e*5/16)
+				g.add(x+1, y+1, e*1/16)
+			}
+		}
+	case Ordered:
+		for y := 0; y < g.h; y++ {
+			for x := 0; x < g.w; x++ {
+				offset := (bayer4[y%4][x%4]+0.5)/16*255 - 127.5
+				dots[y*g.w+x] = !g.bg[y*g.w+x] && g.at(x, y)+offset >= t
+			}
+		}
+	default:
+		for i, v := range g.pix {
+			dots[i] = !g.bg[i] && v >= t
+		}
+	}
+	return dots
+}
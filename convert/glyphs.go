@@ -0,0 +1,222 @@
+package convert
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// Charset selects the glyphs a frame is drawn with
+type Charset int
+
+const (
+	// Braille draws 2x4 dots per cell, the native format of the frames
+	Braille Charset = iota
+	// ASCII picks a character from a density ramp for each cell
+	ASCII
+	// HalfBlock splits each cell into an upper and lower half (▀▄)
+	HalfBlock
+	// Quadrant splits each cell into 2x2 blocks (▘▝▖▗)
+	Quadrant
+	// Sextant splits each cell into 2x3 blocks from Symbols for Legacy
+	// Computing, which not every font has
+	Sextant
+)
+
+// Charsets lists every charset in the order they are offered to clients
+var Charsets = []Charset{Braille, ASCII, HalfBlock, Quadrant, Sextant}
+
+// asciiRamp orders printable characters from empty to dense
+const asciiRamp = " .:-=+*#%@"
+
+// halfBlocks and quadrants are indexed by their lit pixels, bit 0 being the
+// top left and counting across then down
+var (
+	halfBlocks = []rune(" ▀▄█")
+	quadrants  = []rune(" ▘▝▀▖▌▞▛▗▚▐▜▄▙▟█")
+)
+
+// String returns the query string name of the charset
+func (c Charset) String() string {
+	switch c {
+	case ASCII:
+		return "ascii"
+	case HalfBlock:
+		return "half"
+	case Quadrant:
+		return "quadrant"
+	case Sextant:
+		return "sextant"
+	}
+	return "braille"
+}
+
+// ParseCharset reads a client supplied ?charset= value, empty values mean
+// braille
+func ParseCharset(value string) (Charset, error) {
+	switch strings.ToLower(value) {
+	case "", "braille":
+		return Braille, nil
+	case "ascii", "ramp":
+		return ASCII, nil
+	case "half", "halfblock", "half-block":
+		return HalfBlock, nil
+	case "quadrant", "quad":
+		return Quadrant, nil
+	case "sextant":
+		return Sextant, nil
+	}
+	return Braille, fmt.Errorf("invalid charset %q", value)
+}
+
+// pixels returns how many pixels across and down one cell of the charset
+// holds
+func (c Charset) pixels() (int, int) {
+	switch c {
+	case ASCII:
+		return 1, 1
+	case HalfBlock:
+		return 1, 2
+	case Quadrant:
+		return 2, 2
+	case Sextant:
+		return 2, 3
+	}
+	return 2, 4
+}
+
+// glyph returns the block character with the given pixels lit
+func (c Charset) glyph(bits int) rune {
+	switch c {
+	case HalfBlock:
+		return halfBlocks[bits]
+	case Quadrant:
+		return quadrants[bits]
+	case Sextant:
+		// The block skips the patterns that already exist as the space,
+		// left half, right half and full block
+		switch bits {
+		case 0:
+			return ' '
+		case 0b010101:
+			return '▌'
+		case 0b101010:
+			return '▐'
+		case 0b111111:
+			return '█'
+		}
+		index := bits - 1
+		if bits > 0b010101 {
+			index--
+		}
+		if bits > 0b101010 {
+			index--
+		}
+		return rune(0x1fb00 + index)
+	}
+	return ' '
+}
+
+// Recode redraws a braille frame with charset, keeping one cell per braille
+// cell so sizes and colours still line up. The dot grid is box-filtered to
+// the charset's pixels and dithered again, like Rescale. It returns the new
+// frame with its foreground and background colours, backgrounds are only
+// set for half blocks whose halves differ in colour.
+func Recode(frame string, colors [][]color.RGBA, charset Charset) (string, [][]color.RGBA, [][]color.RGBA) {
+	src := brailleGray(frame)
+	if charset == Braille || src.w == 0 || src.h == 0 {
+		return frame, colors, nil
+	}
+	cols, rows := src.w/2, src.h/4
+	pw, ph := charset.pixels()
+	dst := src.resize(cols*pw, rows*ph)
+
+	var top, bottom, bg [][]color.RGBA
+	fg := colors
+	if charset == HalfBlock && colors != nil {
+		top, bottom = halfColors(colors)
+		fg = make([][]color.RGBA, len(colors))
+		bg = make([][]color.RGBA, len(colors))
+	}
+
+	var dots []bool
+	if charset != ASCII {
+		dots = Ordered.apply(dst, DefaultThreshold)
+	}
+
+	var sb strings.Builder
+	for row := 0; row < rows; row++ {
+		line := make([]rune, cols)
+		for col := range line {
+			if charset == ASCII {
+				level := int(dst.at(col, row)/255*float64(len(asciiRamp)-1) + 0.5)
+				line[col] = rune(asciiRamp[level])
+				continue
+			}
+
+			bits := 0
+			for dy := 0; dy < ph; dy++ {
+				for dx := 0; dx < pw; dx++ {
+					if dots[(row*ph+dy)*dst.w+col*pw+dx] {
+						bits |= 1 << (dy*pw + dx)
+					}
+				}
+			}
+			line[col] = charset.glyph(bits)
+
+			// A half block can show two colours, the upper half in the
+			// foreground and the lower half in the background
+			if top != nil && row < len(top) && col < len(top[row]) {
+				if fg[row] == nil {
+					fg[row] = make([]color.RGBA, len(top[row]))
+					bg[row] = make([]color.RGBA, len(top[row]))
+				}
+				switch {
+				case bits == 0b11 && top[row][col] != bottom[row][col]:
+					line[col] = '▀'
+					fg[row][col], bg[row][col] = top[row][col], bottom[row][col]
+				case bits == 0b10:
+					fg[row][col] = bottom[row][col]
+				default:
+					fg[row][col] = top[row][col]
+				}
+			}
+		}
+		sb.WriteString(strings.TrimRight(string(line), " "))
+		sb.WriteByte('\n')
+	}
+	return sb.String(), fg, bg
+}
+
+// halfColors estimates the colours of the upper and lower half of each cell
+// by blending in a quarter of the cell above or below, as the half's centre
+// sits a quarter of a cell from the cell's own
+func halfColors(colors [][]color.RGBA) ([][]color.RGBA, [][]color.RGBA) {
+	top := make([][]color.RGBA, len(colors))
+	bottom := make([][]color.RGBA, len(colors))
+	for y, line := range colors {
+		top[y] = make([]color.RGBA, len(line))
+		bottom[y] = make([]color.RGBA, len(line))
+		for x, c := range line {
+			top[y][x] = blend(c, neighbour(colors, x, y-1, c))
+			bottom[y][x] = blend(c, neighbour(colors, x, y+1, c))
+		}
+	}
+	return top, bottom
+}
+
+// neighbour returns the colour at x, y or fallback if there is none
+func neighbour(colors [][]color.RGBA, x, y int, fallback color.RGBA) color.RGBA {
+	if y < 0 || y >= len(colors) || x >= len(colors[y]) {
+		return fallback
+	}
+	return colors[y][x]
+}
+
+// blend mixes three parts of a with one part of b, rounded to colorStep
+func blend(a, b color.RGBA) color.RGBA {
+	mix := func(x, y uint8) uint8 {
+		return quantize((3*float64(x) + float64(y)) / 4)
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 0xff}
+}
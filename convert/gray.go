@@ -0,0 +1,139 @@
+package convert
+
+import (
+	"image"
+	"math"
+)
+
+// grayImage is a float luminance buffer in the 0-255 range, kept as floats so
+// dithering can carry error between pixels. Background pixels never raise a
+// dot and take no part in dithering.
+type grayImage struct {
+	w, h int
+	pix  []float64
+	bg   []bool
+}
+
+func (g *grayImage) at(x, y int) float64 {
+	return g.pix[y*g.w+x]
+}
+
+func (g *grayImage) set(x, y int, v float64) {
+	g.pix[y*g.w+x] = v
+}
+
+func (g *grayImage) add(x, y int, v float64) {
+	if x < 0 || x >= g.w || y < 0 || y >= g.h || g.bg[y*g.w+x] {
+		return
+	}
+	g.pix[y*g.w+x] += v
+}
+
+func (g *grayImage) invert() {
+	for i, v := range g.pix {
+		g.pix[i] = 255 - v
+	}
+}
+
+// levels marks pixels at or below blackPoint as background and stretches the
+// rest back over the full range, raised to gamma
+func (g *grayImage) levels(blackPoint uint8, gamma float64) {
+	bp := float64(blackPoint)
+	for i, v := range g.pix {
+		if v <= bp {
+			g.bg[i] = true
+		}
+		if g.bg[i] {
+			g.pix[i] = 0
+			continue
+		}
+		g.pix[i] = 255 * math.Pow((v-bp)/(255-bp), gamma)
+	}
+}
+
+// resize box-filters g to w x h, averaging the source pixels each output
+// pixel covers
+func (g *grayImage) resize(w, h int) *grayImage {
+	out := &grayImage{w: w, h: h, pix: make([]float64, w*h), bg: make([]bool, w*h)}
+	for y := 0; y < h; y++ {
+		y0, y1 := span(y, h, g.h)
+		for x := 0; x < w; x++ {
+			x0, x1 := span(x, w, g.w)
+
+			var sum float64
+			var bg int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					sum += g.at(sx, sy)
+					if g.bg[sy*g.w+sx] {
+						bg++
+					}
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			out.set(x, y, sum/float64(n))
+			out.bg[y*w+x] = 2*bg > n
+		}
+	}
+	return out
+}
+
+// span returns the source range [lo, hi) covered by output index i when
+// scaling n source pixels to size outputs, always at least one pixel wide
+func span(i, size, n int) (int, int) {
+	lo := i * n / size
+	hi := (i + 1) * n / size
+	if hi <= lo {
+		hi = lo + 1
+	}
+	if hi > n {
+		hi = n
+		lo = min(lo, n-1)
+	}
+	return lo, hi
+}
+
+// sample box-filters img down (or up) to a w x h luminance buffer. Pixels
+// that are mostly transparent are background.
+func sample(img image.Image, w, h int) *grayImage {
+	b := img.Bounds()
+	g := &grayImage{w: w, h: h, pix: make([]float64, w*h), bg: make([]bool, w*h)}
+
+	for y := 0; y < h; y++ {
+		y0 := b.Min.Y + y*b.Dy()/h
+		y1 := b.Min.Y + (y+1)*b.Dy()/h
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < w; x++ {
+			x0 := b.Min.X + x*b.Dx()/w
+			x1 := b.Min.X + (x+1)*b.Dx()/w
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var sum, alpha float64
+			var n int
+			for sy := y0; sy < y1 && sy < b.Max.Y; sy++ {
+				for sx := x0; sx < x1 && sx < b.Max.X; sx++ {
+					l, a := luminance(img, sx, sy)
+					sum += l
+					alpha += a
+					n++
+				}
+			}
+			if n > 0 && alpha > 0 {
+				g.set(x, y, sum/alpha)
+			}
+			g.bg[y*w+x] = n == 0 || alpha < float64(n)/2
+		}
+	}
+	return g
+}
+
+// luminance returns the Rec. 601 brightness of a pixel in the 0-255 range,
+// premultiplied by its opacity, and the opacity from 0 to 1
+func luminance(img image.Image, x, y int) (float64, float64) {
+	r, g, b, a := img.At(x, y).RGBA()
+	return (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 257, float64(a) / 0xffff
+}
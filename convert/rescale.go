@@ -0,0 +1,50 @@
+package convert
+
+import "strings"
+
+// brailleBase is the first code point of the Unicode braille block
+const brailleBase = 0x2800
+
+// Rescale resamples a braille frame to cols x rows cells. The dot grid is
+// box-filtered to grey levels and dithered again, so shading survives the
+// resize instead of whole cells being dropped. Cells that aren't braille are
+// treated as blank.
+func Rescale(frame string, cols, rows int, dither Dither) string {
+	src := brailleGray(frame)
+	if cols <= 0 || rows <= 0 || src.w == 0 || src.h == 0 {
+		return frame
+	}
+	dst := src.resize(cols*2, rows*4)
+	return braille(dither.apply(dst, DefaultThreshold), cols, rows)
+}
+
+// brailleGray decodes a braille frame into a dot bitmap with raised dots at
+// full brightness
+func brailleGray(frame string) *grayImage {
+	lines := strings.Split(strings.TrimSuffix(frame, "\n"), "\n")
+	var cols int
+	grid := make([][]rune, len(lines))
+	for i, line := range lines {
+		grid[i] = []rune(line)
+		cols = max(cols, len(grid[i]))
+	}
+
+	g := &grayImage{w: cols * 2, h: len(lines) * 4}
+	g.pix = make([]float64, g.w*g.h)
+	g.bg = make([]bool, g.w*g.h)
+	for row, line := range grid {
+		for col, r := range line {
+			if r < brailleBase || r > brailleBase+0xff {
+				continue
+			}
+			for dy := 0; dy < 4; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					if (r-brailleBase)&brailleBits[dy][dx] != 0 {
+						g.set(col*2+dx, row*4+dy, 255)
+					}
+				}
+			}
+		}
+	}
+	return g
+}
@@ -0,0 +1,178 @@
+// Package negotiate decides what kind of output a client gets, so the mux
+// server and the Vercel function answer the same request the same way.
+package negotiate
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Profile is the kind of output served to a client
+type Profile int
+
+const (
+	// ANSI streams the animation with terminal escape sequences
+	ANSI Profile = iota
+	// Plain writes a single frame without escape sequences
+	Plain
+	// HTML serves the browser page
+	HTML
+	// JSON describes the animation instead of playing it
+	JSON
+	// SSE streams the frames as Server-Sent Events
+	SSE
+)
+
+// String returns the ?format= name of the profile
+func (p Profile) String() string {
+	switch p {
+	case Plain:
+		return "text"
+	case HTML:
+		return "html"
+	case JSON:
+		return "json"
+	case SSE:
+		return "sse"
+	}
+	return "ansi"
+}
+
+// ParseFormat reads a client supplied ?format= value. Empty values return
+// false so negotiation carries on with the headers.
+func ParseFormat(value string) (Profile, bool, error) {
+	switch strings.ToLower(value) {
+	case "":
+		return ANSI, false, nil
+	case "ansi", "terminal", "stream":
+		return ANSI, true, nil
+	case "text", "plain", "txt":
+		return Plain, true, nil
+	case "html", "browser":
+		return HTML, true, nil
+	case "json":
+		return JSON, true, nil
+	case "sse", "events":
+		return SSE, true, nil
+	}
+	return ANSI, false, fmt.Errorf("invalid format %q", value)
+}
+
+// DefaultAllow lists User-Agent substrings of terminal clients that get the
+// ANSI stream even if they accept text/html
+var DefaultAllow = []string{
+	"curl", "wget", "httpie", "xh/", "aria2", "powershell",
+	"go-http-client", "python-requests", "python-urllib", "libfetch",
+}
+
+// DefaultDeny lists User-Agent substrings of link preview bots, which fetch
+// a URL to unfurl it and would otherwise hang on an endless stream
+var DefaultDeny = []string{
+	"slackbot", "discordbot", "twitterbot", "facebookexternalhit", "telegrambot",
+	"whatsapp", "linkedinbot", "skypeuripreview",
+}
+
+// Negotiator maps requests to profiles. Allow and Deny hold lower case
+// User-Agent substrings, Deny winning when both match.
+type Negotiator struct {
+	Allow []string // clients that get the ANSI stream
+	Deny  []string // clients that get the HTML page
+}
+
+// Default is the negotiator used when nothing is configured
+var Default = New(nil, nil)
+
+// New creates a negotiator with the default lists extended by allow and
+// deny
+func New(allow, deny []string) Negotiator {
+	return Negotiator{
+		Allow: append(append([]string(nil), DefaultAllow...), lower(allow)...),
+		Deny:  append(append([]string(nil), DefaultDeny...), lower(deny)...),
+	}
+}
+
+// FromEnv creates a negotiator whose extra entries come from the comma
+// separated SEAL_ALLOW_AGENTS and SEAL_DENY_AGENTS variables
+func FromEnv(getenv func(string) string) Negotiator {
+	return New(ParseList(getenv("SEAL_ALLOW_AGENTS")), ParseList(getenv("SEAL_DENY_AGENTS")))
+}
+
+// ParseList splits a comma separated list, dropping empty entries
+func ParseList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Negotiate picks the profile for r. An explicit ?format= wins, then the
+// User-Agent deny list, then an Accept header asking for an event stream,
+// JSON or plain text, then the allow list. Browsers always accept text/html,
+// so any other client is treated as a terminal.
+func (n Negotiator) Negotiate(r *http.Request) (Profile, error) {
+	if profile, ok, err := ParseFormat(r.URL.Query().Get("format")); ok || err != nil {
+		return profile, err
+	}
+
+	agent := strings.ToLower(r.Header.Get("User-Agent"))
+	if contains(agent, n.Deny) {
+		return HTML, nil
+	}
+
+	accept := acceptable(r.Header.Values("Accept"))
+	switch {
+	case accept["text/event-stream"]:
+		return SSE, nil
+	case accept["text/html"] && !contains(agent, n.Allow):
+		return HTML, nil
+	case accept["application/json"] && !accept["text/html"]:
+		return JSON, nil
+	case accept["text/plain"] && !accept["text/html"]:
+		return Plain, nil
+	}
+	return ANSI, nil
+}
+
+// acceptable returns the media types named in Accept headers with a non-zero
+// quality. Wildcards are left out, they say nothing about the client.
+func acceptable(headers []string) map[string]bool {
+	types := map[string]bool{}
+	for _, header := range headers {
+		for _, part := range strings.Split(header, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || strings.HasSuffix(mediaType, "/*") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q <= 0 {
+				continue
+			}
+			types[mediaType] = true
+		}
+	}
+	return types
+}
+
+// contains reports whether agent contains any of substrings
+func contains(agent string, substrings []string) bool {
+	for _, s := range substrings {
+		if strings.Contains(agent, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// lower returns list in lower case
+func lower(list []string) []string {
+	out := make([]string, len(list))
+	for i, s := range list {
+		out[i] = strings.ToLower(s)
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package negotiate
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	browserAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	firefox       = "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		agent  string
+		accept string
+		want   Profile
+		err    string
+	}{
+		{"curl", "", "curl/8.5.0", "*/*", ANSI, ""},
+		{"wget", "", "Wget/1.21.4", "*/*", ANSI, ""},
+		{"powershell", "", "Mozilla/5.0 (Windows NT 10.0; Microsoft Windows 10.0.22631; en-US) PowerShell/7.4.1", "", ANSI, ""},
+		{"powershell accepting html", "", "Mozilla/5.0 (Windows NT; Windows NT 10.0; en-US) WindowsPowerShell/5.1.22621.2506", browserAccept, ANSI, ""},
+		{"browser", "", firefox, browserAccept, HTML, ""},
+		{"slackbot", "", "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", "*/*", HTML, ""},
+		{"slackbot asking for events", "", "Slackbot 1.0", "text/event-stream", HTML, ""},
+		{"deny list wins over allow list", "", "curl/8.5.0 discordbot", "*/*", HTML, ""},
+		{"json", "", "", "application/json", JSON, ""},
+		{"json from a browser", "", firefox, "text/html, application/json", HTML, ""},
+		{"json from curl", "", "curl/8.5.0", "application/json", JSON, ""},
+		{"plain text", "", "", "text/plain", Plain, ""},
+		{"html turned down", "", "", "text/html;q=0, text/plain", Plain, ""},
+		{"html turned down alone", "", "", "text/html;q=0", ANSI, ""},
+		{"event stream", "", firefox, "text/event-stream", SSE, ""},
+		{"event stream over html", "", firefox, "text/html, text/event-stream", SSE, ""},
+		{"no headers", "", "", "", ANSI, ""},
+		{"format wins over headers", "format=json", firefox, browserAccept, JSON, ""},
+		{"format wins over deny list", "format=ansi", "Slackbot 1.0", "*/*", ANSI, ""},
+		{"format is case insensitive", "format=TEXT", "", "", Plain, ""},
+		{"empty format", "format=", firefox, browserAccept, HTML, ""},
+		{"invalid format", "format=gif", "curl/8.5.0", "*/*", ANSI, `invalid format "gif"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/seal?"+tt.query, nil)
+			if tt.agent != "" {
+				r.Header.Set("User-Agent", tt.agent)
+			}
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			got, err := Default.Negotiate(r)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Negotiate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegotiateConfiguredLists(t *testing.T) {
+	n := FromEnv(func(key string) string {
+		return map[string]string{
+			"SEAL_ALLOW_AGENTS": " MyTerm , ",
+			"SEAL_DENY_AGENTS":  "PreviewBot",
+		}[key]
+	})
+	tests := []struct {
+		agent string
+		want  Profile
+	}{
+		{"myterm/1.0", ANSI},
+		{"Mozilla/5.0 PreviewBot/2", HTML},
+		{firefox, HTML},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/seal", nil)
+		r.Header.Set("User-Agent", tt.agent)
+		r.Header.Set("Accept", browserAccept)
+		if got, err := n.Negotiate(r); err != nil || got != tt.want {
+			t.Errorf("%s: Negotiate() = %v, %v, want %v", tt.agent, got, err, tt.want)
+		}
+	}
+}
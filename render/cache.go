@@ -0,0 +1,203 @@
+package render
+
+import (
+	"bytes"
+	"image/color"
+	"sync"
+)
+
+// Frames is the read side of an animation
+type Frames interface {
+	GetFrame(index int) string
+	GetLength() int
+}
+
+// Colored is implemented by animations that carry per-cell colours
+type Colored interface {
+	GetColors(index int) [][]color.RGBA
+}
+
+// Backgrounded is implemented by animations that also colour the background
+// of some cells
+type Backgrounded interface {
+	GetBackgrounds(index int) [][]color.RGBA
+}
+
+// Cache holds the encoded output for an animation's frames so every viewer
+// shares the same bytes instead of re-encoding them on each tick. Entries
+// are built on first use and never modified afterwards.
+type Cache struct {
+	frames Frames
+	footer string
+	mode   ColorMode
+
+	mu        sync.RWMutex
+	cells     map[int][][]cell
+	full      map[int][]byte
+	keyframes map[int][]byte
+	deltas    map[[2]int][]byte
+}
+
+// cacheKey identifies a shared cache
+type cacheKey struct {
+	frames Frames
+	footer string
+	mode   ColorMode
+}
+
+// shared holds the caches of long-lived animations
+var shared sync.Map
+
+// NewCache creates a cache for frames with footer drawn under each frame,
+// coloured with mode if the frames carry colours
+func NewCache(frames Frames, footer string, mode ColorMode) *Cache {
+	if _, ok := frames.(Colored); !ok {
+		mode = NoColor
+	}
+	return &Cache{
+		frames:    frames,
+		footer:    footer,
+		mode:      mode,
+		cells:     map[int][][]cell{},
+		full:      map[int][]byte{},
+		keyframes: map[int][]byte{},
+		deltas:    map[[2]int][]byte{},
+	}
+}
+
+// Shared returns the process wide cache for frames, footer and mode. Use it
+// for registered animations, per-request variants should use NewCache so
+// they are released with the request.
+func Shared(frames Frames, footer string, mode ColorMode) *Cache {
+	key := cacheKey{frames, footer, mode}
+	if c, ok := shared.Load(key); ok {
+		return c.(*Cache)
+	}
+	c, _ := shared.LoadOrStore(key, NewCache(frames, footer, mode))
+	return c.(*Cache)
+}
+
+// frame returns the cells drawn for index
+func (c *Cache) frame(index int) [][]cell {
+	var colors, backgrounds [][]color.RGBA
+	if colored, ok := c.frames.(Colored); ok && c.mode != NoColor {
+		colors = colored.GetColors(index)
+	}
+	if backgrounded, ok := c.frames.(Backgrounded); ok && c.mode != NoColor {
+		backgrounds = backgrounded.GetBackgrounds(index)
+	}
+	return cells(c.frames.GetFrame(index)+c.footer, colors, backgrounds, c.mode)
+}
+
+// lookup returns a cached entry, building it with the lock held on a miss
+func lookup[K comparable, V any](c *Cache, m map[K]V, key K, build func() V) V {
+	c.mu.RLock()
+	v, ok := m[key]
+	c.mu.RUnlock()
+	if ok {
+		return v
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := m[key]; ok {
+		return v
+	}
+	v = build()
+	m[key] = v
+	return v
+}
+
+// grid returns the cells of a frame, the caller must hold c.mu
+func (c *Cache) grid(index int) [][]cell {
+	g, ok := c.cells[index]
+	if !ok {
+		g = c.frame(index)
+		c.cells[index] = g
+	}
+	return g
+}
+
+// Full returns the bytes that clear the screen and draw frame index
+func (c *Cache) Full(index int) []byte {
+	return lookup(c, c.full, index, func() []byte {
+		var buf bytes.Buffer
+		writeFull(&buf, c.grid(index))
+		return buf.Bytes()
+	})
+}
+
+// Keyframe returns the bytes that redraw frame index in place
+func (c *Cache) Keyframe(index int) []byte {
+	return lookup(c, c.keyframes, index, func() []byte {
+		var buf bytes.Buffer
+		writeKeyframe(&buf, c.grid(index))
+		return buf.Bytes()
+	})
+}
+
+// Delta returns the bytes that turn frame from into frame to. Only steps
+// that playback takes, to a neighbouring frame or around the loop, are
+// cached. Other pairs, such as a seek, are built on each call so the cache
+// stays linear in the number of frames.
+func (c *Cache) Delta(from, to int) []byte {
+	build := func() []byte {
+		var buf bytes.Buffer
+		writeDiff(&buf, c.grid(from), c.grid(to))
+		return buf.Bytes()
+	}
+	if c.adjacent(from, to) {
+		return lookup(c, c.deltas, [2]int{from, to}, build)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return build()
+}
+
+// adjacent reports whether playback can step straight from one frame to
+// the other
+func (c *Cache) adjacent(from, to int) bool {
+	last := c.frames.GetLength() - 1
+	switch from - to {
+	case -1, 0, 1, last, -last:
+		return true
+	}
+	return false
+}
+
+// Stream creates a per-viewer cursor over the cache
+func (c *Cache) Stream(delta bool) *Stream {
+	return &Stream{cache: c, delta: delta, keyframeInterval: DefaultKeyframeInterval}
+}
+
+// Stream tracks what one viewer has on screen and picks the cached bytes
+// that move it to the next frame
+type Stream struct {
+	cache            *Cache
+	delta            bool
+	keyframeInterval int
+	prev             int
+	started          bool
+	sinceKeyframe    int
+}
+
+// Render returns the shared bytes that draw frame index. The slice must not
+// be modified.
+func (s *Stream) Render(index int) []byte {
+	var out []byte
+	switch {
+	case !s.delta || !s.started:
+		out = s.cache.Full(index)
+		s.sinceKeyframe = 0
+	case s.keyframeInterval > 0 && s.sinceKeyframe >= s.keyframeInterval:
+		out = s.cache.Keyframe(index)
+		s.sinceKeyframe = 0
+	default:
+		out = s.cache.Delta(s.prev, index)
+		s.sinceKeyframe++
+	}
+
+	s.prev = index
+	s.started = true
+	return out
+}
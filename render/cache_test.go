@@ -0,0 +1,40 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+)
+
+// lines is a minimal Frames
+type lines []string
+
+func (l lines) GetFrame(index int) string { return l[index] }
+func (l lines) GetLength() int            { return len(l) }
+
+func TestDeltaCachesOnlyPlaybackSteps(t *testing.T) {
+	c := NewCache(lines{"a\n", "b\n", "c\n", "d\n", "e\n"}, "", NoColor)
+
+	// Forward, backward and around the loop
+	for _, pair := range [][2]int{{0, 1}, {1, 0}, {4, 0}, {0, 4}, {2, 2}} {
+		c.Delta(pair[0], pair[1])
+	}
+	if len(c.deltas) != 5 {
+		t.Fatalf("cached %d deltas for playback steps, want 5", len(c.deltas))
+	}
+
+	// Seeks draw the same bytes but are not cached
+	for from := range 5 {
+		for to := range 5 {
+			got := c.Delta(from, to)
+			var want bytes.Buffer
+			writeDiff(&want, c.frame(from), c.frame(to))
+			if !bytes.Equal(got, want.Bytes()) {
+				t.Errorf("Delta(%d, %d) = %q, want %q", from, to, got, want.Bytes())
+			}
+		}
+	}
+	// Five repeats, eight neighbours and two wraps
+	if len(c.deltas) != 15 {
+		t.Errorf("cached %d deltas after seeking, want 15", len(c.deltas))
+	}
+}
@@ -0,0 +1,170 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// ColorMode selects how cell colours are encoded as SGR escape sequences
+type ColorMode int
+
+const (
+	NoColor   ColorMode = iota // plain monochrome output
+	ANSI16                     // the 16 standard terminal colours
+	ANSI256                    // the xterm 256 colour palette
+	TrueColor                  // 24-bit RGB
+)
+
+// resetSGR restores the terminal's default attributes
+const resetSGR = "\033[0m"
+
+func (m ColorMode) String() string {
+	switch m {
+	case ANSI16:
+		return "16"
+	case ANSI256:
+		return "256"
+	case TrueColor:
+		return "truecolor"
+	default:
+		return "none"
+	}
+}
+
+// ParseColor reads a client supplied ?color= value. Empty values and "auto"
+// fall back to the client's COLORTERM and TERM, which curl users can pass
+// along with -H "COLORTERM: $COLORTERM". Without either the output stays
+// monochrome.
+func ParseColor(value, colorterm, term string) (ColorMode, error) {
+	switch strings.ToLower(value) {
+	case "", "auto":
+		colorterm, term = strings.ToLower(colorterm), strings.ToLower(term)
+		switch {
+		case colorterm == "truecolor" || colorterm == "24bit":
+			return TrueColor, nil
+		case strings.Contains(term, "256color"):
+			return ANSI256, nil
+		case strings.Contains(term, "color") || strings.HasPrefix(term, "xterm"):
+			return ANSI16, nil
+		}
+		return NoColor, nil
+	case "none", "off", "mono", "0":
+		return NoColor, nil
+	case "16", "ansi":
+		return ANSI16, nil
+	case "256":
+		return ANSI256, nil
+	case "truecolor", "24bit", "rgb":
+		return TrueColor, nil
+	}
+	return NoColor, fmt.Errorf("invalid color %q", value)
+}
+
+// sgr returns the escape sequence that sets the foreground to c
+func (m ColorMode) sgr(c color.RGBA) string {
+	switch m {
+	case ANSI16:
+		i := nearest(c, palette16[:])
+		if i < 8 {
+			return "\033[" + strconv.Itoa(30+i) + "m"
+		}
+		return "\033[" + strconv.Itoa(90+i-8) + "m"
+	case ANSI256:
+		return "\033[38;5;" + strconv.Itoa(index256(c)) + "m"
+	case TrueColor:
+		return fmt.Sprintf("\033[38;2;%d;%d;%dm", c.R, c.G, c.B)
+	}
+	return ""
+}
+
+// bgSGR returns the escape sequence that sets the background to c
+func (m ColorMode) bgSGR(c color.RGBA) string {
+	switch m {
+	case ANSI16:
+		i := nearest(c, palette16[:])
+		if i < 8 {
+			return "\033[" + strconv.Itoa(40+i) + "m"
+		}
+		return "\033[" + strconv.Itoa(100+i-8) + "m"
+	case ANSI256:
+		return "\033[48;5;" + strconv.Itoa(index256(c)) + "m"
+	case TrueColor:
+		return fmt.Sprintf("\033[48;2;%d;%d;%dm", c.R, c.G, c.B)
+	}
+	return ""
+}
+
+// palette16 holds the xterm defaults for the 16 standard colours
+var palette16 = [16]color.RGBA{
+	{0x00, 0x00, 0x00, 0xff}, {0xcd, 0x00, 0x00, 0xff}, {0x00, 0xcd, 0x00, 0xff}, {0xcd, 0xcd, 0x00, 0xff},
+	{0x00, 0x00, 0xee, 0xff}, {0xcd, 0x00, 0xcd, 0xff}, {0x00, 0xcd, 0xcd, 0xff}, {0xe5, 0xe5, 0xe5, 0xff},
+	{0x7f, 0x7f, 0x7f, 0xff}, {0xff, 0x00, 0x00, 0xff}, {0x00, 0xff, 0x00, 0xff}, {0xff, 0xff, 0x00, 0xff},
+	{0x5c, 0x5c, 0xff, 0xff}, {0xff, 0x00, 0xff, 0xff}, {0x00, 0xff, 0xff, 0xff}, {0xff, 0xff, 0xff, 0xff},
+}
+
+// cubeLevels are the channel values of the 6x6x6 cube in the 256 palette
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// index256 picks the closer of the nearest cube colour and grey ramp entry
+func index256(c color.RGBA) int {
+	level := func(v uint8) int {
+		best := 0
+		for i, l := range cubeLevels {
+			if abs(int(v)-l) < abs(int(v)-cubeLevels[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	r, g, b := level(c.R), level(c.G), level(c.B)
+	cube := color.RGBA{uint8(cubeLevels[r]), uint8(cubeLevels[g]), uint8(cubeLevels[b]), 0xff}
+
+	grey := min(max((int(c.R)+int(c.G)+int(c.B))/3-8, 0)/10, 23)
+	v := uint8(8 + grey*10)
+	if distance(c, color.RGBA{v, v, v, 0xff}) < distance(c, cube) {
+		return 232 + grey
+	}
+	return 16 + 36*r + 6*g + b
+}
+
+// Palette returns the xterm default for entry index of the 256 colour
+// palette, as selected by SGR 38;5;index. Out of range indices are black.
+func Palette(index int) color.RGBA {
+	switch {
+	case index >= 0 && index < 16:
+		return palette16[index]
+	case index >= 16 && index < 232:
+		index -= 16
+		return color.RGBA{uint8(cubeLevels[index/36]), uint8(cubeLevels[index/6%6]), uint8(cubeLevels[index%6]), 0xff}
+	case index >= 232 && index < 256:
+		v := uint8(8 + (index-232)*10)
+		return color.RGBA{v, v, v, 0xff}
+	}
+	return color.RGBA{A: 0xff}
+}
+
+// nearest returns the index of the palette entry closest to c
+func nearest(c color.RGBA, palette []color.RGBA) int {
+	best := 0
+	for i, p := range palette {
+		if distance(c, p) < distance(c, palette[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// distance is the squared RGB distance between two colours
+func distance(a, b color.RGBA) int {
+	dr, dg, db := int(a.R)-int(b.R), int(a.G)-int(b.G), int(a.B)-int(b.B)
+	return dr*dr + dg*dg + db*db
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
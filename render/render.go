@@ -0,0 +1,225 @@
+// Package render turns animation frames into terminal output.
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ClearScreen clears the terminal and homes the cursor
+	ClearScreen = "\033[2J\033[H"
+
+	// DefaultKeyframeInterval is how many frames pass between full redraws,
+	// which repair any drift if a client dropped or mangled a delta
+	DefaultKeyframeInterval = 50
+
+	// mergeGap is the longest run of unchanged cells written through rather
+	// than skipped, since a cursor move costs about as much as a few cells
+	mergeGap = 3
+
+	home      = "\033[H"
+	clearLine = "\033[K"
+	clearDown = "\033[J"
+)
+
+// ParseDelta reads a client supplied ?delta= value. Empty values default to
+// delta rendering.
+func ParseDelta(value string) (bool, error) {
+	if value == "" {
+		return true, nil
+	}
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid delta %q", value)
+	}
+	return on, nil
+}
+
+// cell is one character on screen with the SGR sequence that colours it
+type cell struct {
+	r   rune
+	sgr string
+	bg  bool // sgr sets the background too
+}
+
+// blank reports whether the cell shows nothing, so its colour is irrelevant
+func (c cell) blank() bool {
+	return c.r == ' ' || c.r == '\u2800'
+}
+
+// painter writes cells, only emitting SGR sequences when the colour of a
+// visible cell changes. Blank cells keep the current foreground but not a
+// background, which would show.
+type painter struct {
+	buf *bytes.Buffer
+	sgr string
+	bg  bool
+}
+
+// write draws a cell, switching colour first if needed
+func (p *painter) write(c cell) {
+	if (!c.blank() || p.bg) && c.sgr != p.sgr {
+		switch {
+		case c.sgr == "" || p.bg:
+			// A new foreground alone would leave the old background set
+			p.buf.WriteString(resetSGR)
+			p.buf.WriteString(c.sgr)
+		default:
+			p.buf.WriteString(c.sgr)
+		}
+		p.sgr, p.bg = c.sgr, c.bg
+	}
+	p.buf.WriteRune(c.r)
+}
+
+// endLine drops a background colour before the line is cleared or ended,
+// which would otherwise fill the rest of the line
+func (p *painter) endLine() {
+	if p.bg {
+		p.reset()
+	}
+}
+
+// reset restores the default colour so the output can be cut after it
+func (p *painter) reset() {
+	if p.sgr != "" {
+		p.buf.WriteString(resetSGR)
+		p.sgr, p.bg = "", false
+	}
+}
+
+// writeFull clears the screen and draws frame
+func writeFull(buf *bytes.Buffer, cur [][]cell) {
+	buf.WriteString(ClearScreen)
+	p := painter{buf: buf}
+	for _, line := range cur {
+		for _, c := range line {
+			p.write(c)
+		}
+		p.endLine()
+		buf.WriteByte('\n')
+	}
+	p.reset()
+}
+
+// writeKeyframe redraws every line in place without clearing the screen
+// first, which avoids the flicker of a full clear
+func writeKeyframe(buf *bytes.Buffer, cur [][]cell) {
+	buf.WriteString(home)
+	p := painter{buf: buf}
+	for _, line := range cur {
+		for _, c := range line {
+			p.write(c)
+		}
+		p.endLine()
+		buf.WriteString(clearLine)
+		buf.WriteByte('\n')
+	}
+	p.reset()
+	buf.WriteString(clearDown)
+}
+
+// writeDiff writes cursor moves and runs of the cells that differ between
+// prev and cur, falling back to a keyframe if the line count changed
+func writeDiff(buf *bytes.Buffer, prev, cur [][]cell) {
+	if len(prev) != len(cur) {
+		writeKeyframe(buf, cur)
+		return
+	}
+
+	p := painter{buf: buf}
+	changed := false
+	for row, line := range cur {
+		old := prev[row]
+		width := max(len(line), len(old))
+
+		for col := 0; col < width; {
+			if cellAt(line, col) == cellAt(old, col) {
+				col++
+				continue
+			}
+
+			// Extend the run across short stretches of unchanged cells
+			start, end := col, col+1
+			for next := end; next < width && next-end <= mergeGap; next++ {
+				if cellAt(line, next) != cellAt(old, next) {
+					end = next + 1
+				}
+			}
+
+			moveTo(buf, row, start)
+			for c := start; c < end; c++ {
+				p.write(cellAt(line, c))
+			}
+			p.endLine()
+			changed = true
+			col = end
+		}
+	}
+
+	if changed {
+		p.reset()
+		moveTo(buf, len(cur), 0)
+	}
+}
+
+// moveTo positions the cursor at a zero based row and column
+func moveTo(buf *bytes.Buffer, row, col int) {
+	buf.WriteString("\033[")
+	buf.WriteString(strconv.Itoa(row + 1))
+	buf.WriteByte(';')
+	buf.WriteString(strconv.Itoa(col + 1))
+	buf.WriteByte('H')
+}
+
+// cells splits a frame into lines of cells coloured from colors and
+// backgrounds, rows and cells without a colour are drawn in the terminal's
+// default. Backgrounds with a zero alpha are left unset.
+func cells(frame string, colors, backgrounds [][]color.RGBA, mode ColorMode) [][]cell {
+	lines := strings.Split(strings.TrimSuffix(frame, "\n"), "\n")
+	grid := make([][]cell, len(lines))
+	styles := map[[2]color.RGBA]cell{}
+	for i, line := range lines {
+		runes := []rune(line)
+		grid[i] = make([]cell, len(runes))
+		for j, r := range runes {
+			c := cell{r: r}
+			if mode != NoColor && !c.blank() && i < len(colors) && j < len(colors[i]) {
+				var key [2]color.RGBA
+				key[0] = colors[i][j]
+				if i < len(backgrounds) && j < len(backgrounds[i]) {
+					key[1] = backgrounds[i][j]
+				}
+				style, ok := styles[key]
+				if !ok {
+					style.sgr = mode.sgr(key[0])
+					// Skip backgrounds the palette can't tell apart from
+					// the foreground
+					if key[1].A != 0 && mode.sgr(key[1]) != style.sgr {
+						style.sgr += mode.bgSGR(key[1])
+						style.bg = true
+					}
+					styles[key] = style
+				}
+				c.sgr, c.bg = style.sgr, style.bg
+				if key[1].A != 0 && !c.bg && c.r == '▀' {
+					c.r = '█' // both halves ended up the same colour
+				}
+			}
+			grid[i][j] = c
+		}
+	}
+	return grid
+}
+
+// cellAt returns the cell at col, treating cells past the end as blank
+func cellAt(line []cell, col int) cell {
+	if col < len(line) {
+		return line[col]
+	}
+	return cell{r: ' '}
+}
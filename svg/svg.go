@@ -0,0 +1,333 @@
+// Package svg renders animations as standalone animated SVG images, which
+// play wherever an image can be shown, READMEs included, without a script.
+//
+// Every frame shown is drawn once, as a group of text elements, one per
+// line. All groups start hidden and each has its own CSS keyframes that make
+// it visible while its frame is on screen, stepping without easing.
+package svg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"seal-ascii/animations"
+)
+
+const (
+	// ContentType is the media type of the images
+	ContentType = "image/svg+xml"
+
+	// DefaultFontSize is the font size, in pixels, used when Options leaves
+	// it unset
+	DefaultFontSize = 14
+
+	// DefaultMaxSize keeps images well inside what image proxies, such as
+	// the one GitHub puts in front of README images, are willing to serve
+	DefaultMaxSize = 1 << 20
+
+	// cellWidth and lineHeight are the monospace cell size in ems. Two to
+	// one suits braille, whose 2x4 dots come out square.
+	cellWidth  = 0.6
+	lineHeight = 1.2
+
+	// fonts is the font stack, common monospace fonts first
+	fonts = `ui-monospace, SFMono-Regular, Menlo, Consolas, "DejaVu Sans Mono", "Liberation Mono", monospace`
+
+	// lightText and darkText colour uncoloured cells on light and dark pages
+	lightText = "#24292f"
+	darkText  = "#e6edf3"
+
+	// upperHalf shows two colours in a half block cell, the lower half
+	// being the background
+	upperHalf = '▀'
+	fullBlock = '█'
+)
+
+// ErrTooLarge is returned when not even a single frame fits in MaxSize
+var ErrTooLarge = errors.New("image too large")
+
+// Options control how an animation is drawn
+type Options struct {
+	Title    string
+	Color    bool                // use the frames' colours and backgrounds, if any
+	Playback animations.Playback // a finite playback stops on its last frame
+	FontSize int                 // in pixels, DefaultFontSize if zero
+	MaxSize  int                 // in bytes, 0 for no limit
+}
+
+// step is one frame shown during a pass
+type step struct {
+	index int
+	start time.Duration
+}
+
+// Encode writes animation to w as an animated SVG. When the image would be
+// larger than MaxSize, frames are dropped evenly, the ones kept staying up
+// for the ones they replace, until it fits.
+func Encode(w io.Writer, animation *animations.FrameType, opts Options) error {
+	if animation.GetLength() == 0 {
+		return errors.New("no frames to draw")
+	}
+	if opts.MaxSize <= 0 {
+		bw := bufio.NewWriter(w)
+		encode(bw, animation, opts)
+		return bw.Flush()
+	}
+
+	var buf bytes.Buffer
+	for n := 1; ; {
+		decimated := animation.Decimate(n)
+		buf.Reset()
+		encode(&buf, decimated, opts)
+		if buf.Len() <= opts.MaxSize {
+			_, err := w.Write(buf.Bytes())
+			return err
+		}
+		if decimated.GetLength() == 1 {
+			return fmt.Errorf("%w: a frame takes %d bytes, more than %d", ErrTooLarge, buf.Len(), opts.MaxSize)
+		}
+		// The size is close to proportional to the frames kept
+		n = max(n+1, int(math.Ceil(float64(n)*float64(buf.Len())/float64(opts.MaxSize))))
+	}
+}
+
+// writer is what encode writes to, a bufio.Writer or bytes.Buffer
+type writer interface {
+	io.Writer
+	io.StringWriter
+}
+
+// encode draws animation into bw
+func encode(bw writer, animation *animations.FrameType, opts Options) {
+	fontSize := float64(opts.FontSize)
+	if fontSize <= 0 {
+		fontSize = DefaultFontSize
+	}
+	cw, lh := cellWidth*fontSize, lineHeight*fontSize
+	cols, rows := animation.Size()
+	cols, rows = max(cols, 1), max(rows, 1)
+	width, height := float64(cols)*cw, float64(rows)*lh
+
+	// One pass in playback order. Finite playbacks run the pass once per
+	// loop and rest on the last frame, which for a ping-pong is the first.
+	pass := opts.Playback
+	pass.Loops = 1
+	sequence := animations.NewSequence(animation.GetLength(), pass)
+	var steps []step
+	var total time.Duration
+	for {
+		index, ok := sequence.Next()
+		if !ok {
+			break
+		}
+		steps = append(steps, step{index, total})
+		total += animation.GetSleep(index)
+	}
+	final := steps[len(steps)-1].index
+	if pass.Mode == animations.PingPong && len(steps) > 1 {
+		steps = steps[:len(steps)-1]
+		total -= animation.GetSleep(final)
+	}
+	iterations := "infinite"
+	if opts.Playback.Finite() {
+		iterations = strconv.Itoa(opts.Playback.Loops)
+	}
+
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s" xml:space="preserve" role="img"`,
+		num(width), num(height), num(width), num(height))
+	if opts.Title != "" {
+		bw.WriteString(` aria-label="`)
+		xml.EscapeText(bw, []byte(opts.Title))
+		bw.WriteString(`"`)
+	}
+	bw.WriteString(">\n")
+	if opts.Title != "" {
+		bw.WriteString("<title>")
+		xml.EscapeText(bw, []byte(opts.Title))
+		bw.WriteString("</title>\n")
+	}
+
+	bw.WriteString("<style>\n")
+	fmt.Fprintf(bw, "text{font-family:%s;font-size:%spx;white-space:pre;fill:%s}\n", fonts, num(fontSize), lightText)
+	fmt.Fprintf(bw, "@media (prefers-color-scheme:dark){text{fill:%s}}\n", darkText)
+	if len(steps) == 1 {
+		bw.WriteString("</style>\n")
+		writeFrame(bw, animation, final, opts.Color, cw, lh, fontSize)
+		bw.WriteString("</svg>\n")
+		return
+	}
+
+	// Each frame's keyframes switch it on at the start of each of its steps
+	// and off at the start of the next, holding in between. The last
+	// keyframe is what a finite playback rests on.
+	shown := make(map[int][]int) // steps of each frame
+	var order []int              // frames in order of first appearance
+	for k, s := range steps {
+		if _, ok := shown[s.index]; !ok {
+			order = append(order, s.index)
+		}
+		shown[s.index] = append(shown[s.index], k)
+	}
+	fmt.Fprintf(bw, ".f{visibility:hidden;animation:%dms steps(1,end) %s forwards}\n", total.Milliseconds(), iterations)
+	for _, index := range order {
+		fmt.Fprintf(bw, "@keyframes k%d{", index)
+		if steps[0].index != index {
+			bw.WriteString("0%{visibility:hidden}")
+		}
+		for _, k := range shown[index] {
+			fmt.Fprintf(bw, "%s%%{visibility:visible}", percent(steps[k].start, total))
+			if k+1 < len(steps) && steps[k+1].index != index {
+				fmt.Fprintf(bw, "%s%%{visibility:hidden}", percent(steps[k+1].start, total))
+			}
+		}
+		end := "hidden"
+		if index == final {
+			end = "visible"
+		}
+		fmt.Fprintf(bw, "100%%{visibility:%s}}\n", end)
+	}
+	bw.WriteString("</style>\n")
+
+	for _, index := range order {
+		fmt.Fprintf(bw, `<g class="f" style="animation-name:k%d">`+"\n", index)
+		writeFrame(bw, animation, index, opts.Color, cw, lh, fontSize)
+		bw.WriteString("</g>\n")
+	}
+	bw.WriteString("</svg>\n")
+}
+
+// writeFrame draws frame index, its backgrounds under its text. Each line
+// is a text element starting at its first drawn cell and stretched to whole
+// cells, so fonts whose braille is narrower or wider than their letters
+// still line up. Without colour, half blocks whose lower half is a
+// background are drawn whole.
+func writeFrame(bw writer, animation *animations.FrameType, index int, colored bool, cw, lh, fontSize float64) {
+	var colors [][]color.RGBA
+	backgrounds := animation.GetBackgrounds(index)
+	if colored {
+		colors = animation.GetColors(index)
+		for row, line := range backgrounds {
+			writeBackgrounds(bw, line, float64(row)*lh, cw, lh)
+		}
+	}
+
+	for row, line := range strings.Split(strings.TrimSuffix(animation.GetFrame(index), "\n"), "\n") {
+		runes := []rune(line)
+		if !colored && row < len(backgrounds) {
+			for x, bg := range backgrounds[row] {
+				if x < len(runes) && bg.A != 0 && runes[x] == upperHalf {
+					runes[x] = fullBlock
+				}
+			}
+		}
+		first, last := 0, len(runes)
+		for first < last && isBlank(runes[first]) {
+			first++
+		}
+		for last > first && isBlank(runes[last-1]) {
+			last--
+		}
+		if first == last {
+			continue
+		}
+
+		// Baseline a little above the bottom of the line leaves room for
+		// descenders
+		baseline := float64(row)*lh + fontSize
+		fmt.Fprintf(bw, `<text x="%s" y="%s" textLength="%s" lengthAdjust="spacingAndGlyphs">`,
+			num(float64(first)*cw), num(baseline), num(float64(last-first)*cw))
+		var rowColors []color.RGBA
+		if row < len(colors) {
+			rowColors = colors[row]
+		}
+		writeRuns(bw, runes[first:last], rowColors, first)
+		bw.WriteString("</text>\n")
+	}
+}
+
+// writeBackgrounds fills the cells of a line that have a background, one
+// rectangle per run of a colour. Cells with a zero alpha have none.
+func writeBackgrounds(bw writer, line []color.RGBA, y, cw, lh float64) {
+	for x := 0; x < len(line); {
+		c := line[x]
+		run := 1
+		for x+run < len(line) && line[x+run] == c {
+			run++
+		}
+		if c.A != 0 {
+			fmt.Fprintf(bw, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`+"\n",
+				num(float64(x)*cw), num(y), num(float64(run)*cw), num(lh), hex(c))
+		}
+		x += run
+	}
+}
+
+// writeRuns writes the cells of a line, wrapping runs of one colour in a
+// tspan. Blank cells take whatever colour is current. Cells past the end of
+// colors keep the default.
+func writeRuns(bw writer, runes []rune, colors []color.RGBA, offset int) {
+	var run []rune
+	var current string
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		if current != "" {
+			fmt.Fprintf(bw, `<tspan fill="%s">`, current)
+		}
+		xml.EscapeText(bw, []byte(string(run)))
+		if current != "" {
+			bw.WriteString("</tspan>")
+		}
+		run = run[:0]
+	}
+	for i, r := range runes {
+		if isBlank(r) {
+			run = append(run, ' ')
+			continue
+		}
+		fill := ""
+		if x := offset + i; x < len(colors) {
+			fill = hex(colors[x])
+		}
+		if fill != current {
+			flush()
+			current = fill
+		}
+		run = append(run, r)
+	}
+	flush()
+}
+
+// isBlank reports whether a cell draws nothing
+func isBlank(r rune) bool {
+	return r == animations.BrailleBlank || r == ' '
+}
+
+// hex formats a colour for a fill attribute
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// percent formats d as a share of total for a keyframe selector
+func percent(d, total time.Duration) string {
+	return num(100 * d.Seconds() / total.Seconds())
+}
+
+// num formats a length or percentage with at most three decimals
+func num(v float64) string {
+	v = math.Round(v*1000) / 1000
+	if v == 0 {
+		v = 0 // not -0
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,332 @@
+// Package telnet speaks just enough of the telnet protocol to stream
+// animations: it learns the client's window size (NAWS, RFC 1073) and
+// terminal type (RFC 1091), puts the client in character mode and strips
+// commands out of what the client types.
+package telnet
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Commands and options used here, RFC 854 and friends
+const (
+	se   = 240
+	ip   = 244 // interrupt process, sent by many clients for Ctrl+C
+	sb   = 250
+	will = 251
+	wont = 252
+	do   = 253
+	dont = 254
+	iac  = 255
+
+	optBinary = 0
+	optEcho   = 1
+	optSGA    = 3 // suppress go ahead, together with echo this is character mode
+	optTTYPE  = 24
+	optNAWS   = 31
+
+	ttypeIS   = 0
+	ttypeSend = 1
+
+	// maxSubnegotiation bounds a subnegotiation, real ones are a few bytes
+	maxSubnegotiation = 256
+)
+
+// Interrupt is what Read returns for IAC IP, the same byte as Ctrl+C
+const Interrupt = 0x03
+
+// Conn is the server side of a telnet connection. Read returns what the
+// client typed with telnet commands removed, and Write escapes IAC and
+// turns newlines into CR LF.
+type Conn struct {
+	conn         net.Conn
+	r            *bufio.Reader
+	writeTimeout time.Duration
+
+	mu       sync.Mutex // guards the fields below and writes
+	cols     int
+	rows     int
+	term     string
+	answers  map[byte]byte // the client's WILL, WONT, DO or DONT per option
+	onResize func(cols, rows int)
+
+	pending []byte // data read while negotiating
+	cr      bool   // the previous data byte was CR
+}
+
+// NewConn wraps conn, bounding each write by writeTimeout if it is set
+func NewConn(conn net.Conn, writeTimeout time.Duration) *Conn {
+	return &Conn{
+		conn:         conn,
+		r:            bufio.NewReader(conn),
+		writeTimeout: writeTimeout,
+		answers:      map[byte]byte{},
+	}
+}
+
+// Negotiate asks the client for character mode, its window size and its
+// terminal type, and waits up to timeout for the answers. Clients that
+// don't speak telnet, like netcat, just never answer.
+func (c *Conn) Negotiate(timeout time.Duration) error {
+	_, err := c.command(
+		iac, will, optEcho, iac, will, optSGA, iac, do, optSGA,
+		iac, will, optBinary, iac, do, optBinary,
+		iac, do, optNAWS, iac, do, optTTYPE,
+	)
+	if err != nil {
+		return err
+	}
+
+	c.conn.SetReadDeadline(time.Now().Add(timeout))
+	defer c.conn.SetReadDeadline(time.Time{})
+	buf := make([]byte, 64)
+	for !c.negotiated() {
+		n, err := c.read(buf)
+		c.pending = append(c.pending, buf[:n]...)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// negotiated reports whether the window size and terminal type questions
+// have been answered
+func (c *Conn) negotiated() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return (c.cols > 0 || c.answers[optNAWS] == wont) && (c.term != "" || c.answers[optTTYPE] == wont)
+}
+
+// Size returns the client's window size, 0 when it didn't say
+func (c *Conn) Size() (cols, rows int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cols, c.rows
+}
+
+// Term returns the client's terminal type in lower case, empty when it
+// didn't say
+func (c *Conn) Term() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.term
+}
+
+// Echo reports whether the client agreed to let the server echo what it
+// types
+func (c *Conn) Echo() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.answers[optEcho] == do
+}
+
+// OnResize sets a function called from Read whenever the client reports a
+// new window size
+func (c *Conn) OnResize(f func(cols, rows int)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onResize = f
+}
+
+// Read reads what the client typed. CR LF and CR NUL come through as a
+// single CR, and IAC IP as Interrupt.
+func (c *Conn) Read(p []byte) (int, error) {
+	if len(c.pending) > 0 {
+		n := copy(p, c.pending)
+		c.pending = c.pending[n:]
+		return n, nil
+	}
+	for {
+		n, err := c.read(p)
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+// read decodes the bytes available from the connection into p, returning 0
+// and no error when they were all commands
+func (c *Conn) read(p []byte) (int, error) {
+	n := 0
+	b, err := c.r.ReadByte()
+	for err == nil && n < len(p) {
+		switch {
+		case b == iac:
+			if b, err = c.r.ReadByte(); err != nil {
+				break
+			}
+			if b == iac {
+				p[n], n = iac, n+1
+				break
+			}
+			var data byte
+			if data, err = c.handle(b); data != 0 {
+				p[n], n = data, n+1
+			}
+		case c.cr && (b == '\n' || b == 0):
+			// the rest of a CR LF or CR NUL pair
+		default:
+			p[n], n = b, n+1
+		}
+		if err == nil {
+			c.cr = b == '\r'
+		}
+
+		// Carry on only while more input is already buffered
+		if err != nil || c.r.Buffered() == 0 {
+			break
+		}
+		b, err = c.r.ReadByte()
+	}
+	return n, err
+}
+
+// handle acts on the command after an IAC, returning any data byte it
+// stands for
+func (c *Conn) handle(cmd byte) (byte, error) {
+	switch cmd {
+	case ip:
+		return Interrupt, nil
+	case will, wont, do, dont:
+		option, err := c.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		return 0, c.option(cmd, option)
+	case sb:
+		return 0, c.subnegotiation()
+	}
+	return 0, nil // NOP, GA, AYT and the like
+}
+
+// option handles the client's answer to an option, or its own request
+func (c *Conn) option(cmd, option byte) error {
+	c.mu.Lock()
+	first := c.answers[option] == 0
+	c.answers[option] = cmd
+	c.mu.Unlock()
+	if !first {
+		return nil
+	}
+
+	switch {
+	case cmd == will && option == optTTYPE:
+		_, err := c.command(iac, sb, optTTYPE, ttypeSend, iac, se)
+		return err
+	case cmd == will && (option == optNAWS || option == optSGA || option == optBinary):
+		return nil // answers to our DO
+	case cmd == do && (option == optEcho || option == optSGA || option == optBinary):
+		return nil // answers to our WILL
+	case cmd == will:
+		_, err := c.command(iac, dont, option)
+		return err
+	case cmd == do:
+		_, err := c.command(iac, wont, option)
+		return err
+	}
+	return nil
+}
+
+// subnegotiation reads an IAC SB ... IAC SE block and records the window
+// size or terminal type it carries
+func (c *Conn) subnegotiation() error {
+	var body []byte
+	for {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b == iac {
+			if b, err = c.r.ReadByte(); err != nil {
+				return err
+			}
+			if b == se {
+				break
+			}
+		}
+		if len(body) < maxSubnegotiation {
+			body = append(body, b)
+		}
+	}
+	if len(body) == 0 {
+		return nil
+	}
+
+	switch body[0] {
+	case optNAWS:
+		if len(body) < 5 {
+			return nil
+		}
+		cols := int(body[1])<<8 | int(body[2])
+		rows := int(body[3])<<8 | int(body[4])
+		c.mu.Lock()
+		c.cols, c.rows = cols, rows
+		onResize := c.onResize
+		c.mu.Unlock()
+		if onResize != nil {
+			onResize(cols, rows)
+		}
+	case optTTYPE:
+		if len(body) < 2 || body[1] != ttypeIS {
+			return nil
+		}
+		c.mu.Lock()
+		c.term = string(bytes.ToLower(body[2:]))
+		c.mu.Unlock()
+	}
+	return nil
+}
+
+// command writes raw protocol bytes
+func (c *Conn) command(b ...byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.write(b)
+}
+
+// write sends b with the write timeout, c.mu is held
+func (c *Conn) write(b []byte) (int, error) {
+	if c.writeTimeout > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+	return c.conn.Write(b)
+}
+
+// Write sends p as terminal output
+func (c *Conn) Write(p []byte) (int, error) {
+	out := make([]byte, 0, len(p)+len(p)/16)
+	prev := byte(0)
+	for _, b := range p {
+		switch {
+		case b == iac:
+			out = append(out, iac, iac)
+		case b == '\n' && prev != '\r':
+			out = append(out, '\r', '\n')
+		default:
+			out = append(out, b)
+		}
+		prev = b
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the connection
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,239 @@
+package telnet
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// client is the far end of a pipe to a Conn. Everything the Conn writes is
+// read in the background so its replies never block.
+type client struct {
+	t      *testing.T
+	conn   net.Conn
+	out    chan []byte
+	unread []byte
+}
+
+// pipe connects a new Conn to a client
+func pipe(t *testing.T) (*Conn, *client) {
+	t.Helper()
+	server, conn := net.Pipe()
+	t.Cleanup(func() {
+		server.Close()
+		conn.Close()
+	})
+	cl := &client{t: t, conn: conn, out: make(chan []byte, 16)}
+	go func() {
+		defer close(cl.out)
+		for {
+			buf := make([]byte, 256)
+			n, err := conn.Read(buf)
+			if n > 0 {
+				cl.out <- buf[:n]
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return NewConn(server, time.Second), cl
+}
+
+// send writes b to the Conn in the background, net.Pipe writes block until
+// the other end reads
+func (cl *client) send(b ...byte) {
+	go cl.conn.Write(b)
+}
+
+// expect fails the test unless the Conn writes want next
+func (cl *client) expect(want ...byte) {
+	cl.t.Helper()
+	timeout := time.After(time.Second)
+	for len(cl.unread) < len(want) {
+		select {
+		case chunk, ok := <-cl.out:
+			if !ok {
+				cl.t.Fatalf("connection closed after %v, want %v", cl.unread, want)
+			}
+			cl.unread = append(cl.unread, chunk...)
+		case <-timeout:
+			cl.t.Fatalf("got %v, want %v", cl.unread, want)
+		}
+	}
+	if !bytes.Equal(cl.unread[:len(want)], want) {
+		cl.t.Fatalf("got %v, want %v", cl.unread, want)
+	}
+	cl.unread = cl.unread[len(want):]
+}
+
+// quiet fails the test if the Conn has written anything more
+func (cl *client) quiet() {
+	cl.t.Helper()
+	select {
+	case chunk := <-cl.out:
+		cl.unread = append(cl.unread, chunk...)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if len(cl.unread) > 0 {
+		cl.t.Fatalf("unexpected %v", cl.unread)
+	}
+}
+
+// read reads exactly n bytes of client input from c
+func read(t *testing.T, c *Conn, n int) []byte {
+	t.Helper()
+	buf := make([]byte, n)
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.ReadFull(c, buf)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out reading %d bytes", n)
+	}
+	return buf
+}
+
+func TestNegotiate(t *testing.T) {
+	c, cl := pipe(t)
+	var resized [][2]int
+	c.OnResize(func(cols, rows int) { resized = append(resized, [2]int{cols, rows}) })
+
+	done := make(chan error, 1)
+	go func() { done <- c.Negotiate(time.Second) }()
+	cl.expect(
+		iac, will, optEcho, iac, will, optSGA, iac, do, optSGA,
+		iac, will, optBinary, iac, do, optBinary,
+		iac, do, optNAWS, iac, do, optTTYPE,
+	)
+
+	// A 255x511 window, where both 255s are doubled IACs, and a key
+	// pressed before negotiation is over
+	cl.send(
+		iac, do, optEcho, iac, will, optNAWS,
+		iac, sb, optNAWS, 0, iac, iac, 1, iac, iac, iac, se,
+		'q',
+		iac, will, optTTYPE,
+	)
+	cl.expect(iac, sb, optTTYPE, ttypeSend, iac, se)
+	cl.send(append(append([]byte{iac, sb, optTTYPE, ttypeIS}, "XTERM-256color"...), iac, se)...)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Negotiate didn't return once answered")
+	}
+	if cols, rows := c.Size(); cols != 255 || rows != 511 {
+		t.Errorf("Size() = %dx%d, want 255x511", cols, rows)
+	}
+	if len(resized) != 1 || resized[0] != [2]int{255, 511} {
+		t.Errorf("OnResize saw %v", resized)
+	}
+	if term := c.Term(); term != "xterm-256color" {
+		t.Errorf("Term() = %q", term)
+	}
+	if !c.Echo() {
+		t.Error("Echo() = false after DO ECHO")
+	}
+	if got := read(t, c, 1); string(got) != "q" {
+		t.Errorf("Read() = %q, want the key typed while negotiating", got)
+	}
+	cl.quiet()
+}
+
+func TestNegotiateRefused(t *testing.T) {
+	c, cl := pipe(t)
+	done := make(chan error, 1)
+	go func() { done <- c.Negotiate(time.Second) }()
+	cl.expect(
+		iac, will, optEcho, iac, will, optSGA, iac, do, optSGA,
+		iac, will, optBinary, iac, do, optBinary,
+		iac, do, optNAWS, iac, do, optTTYPE,
+	)
+	cl.send(iac, wont, optNAWS, iac, wont, optTTYPE, iac, dont, optEcho)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if cols, rows := c.Size(); cols != 0 || rows != 0 {
+		t.Errorf("Size() = %dx%d, want 0x0", cols, rows)
+	}
+	if c.Term() != "" || c.Echo() {
+		t.Errorf("Term() = %q, Echo() = %v", c.Term(), c.Echo())
+	}
+	cl.quiet()
+}
+
+func TestOptionReplies(t *testing.T) {
+	const unknown = 99
+	c, cl := pipe(t)
+	go io.Copy(io.Discard, c)
+
+	// Options the server didn't ask about are refused, once
+	cl.send(iac, will, unknown)
+	cl.expect(iac, dont, unknown)
+	cl.send(iac, do, unknown+1)
+	cl.expect(iac, wont, unknown+1)
+	cl.send(iac, will, unknown, iac, do, unknown+1)
+	cl.quiet()
+
+	// Answers to the server's own questions need no reply
+	cl.send(iac, will, optNAWS, iac, will, optSGA, iac, do, optSGA, iac, do, optEcho, iac, wont, optBinary)
+	cl.quiet()
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want []byte
+	}{
+		{"text", []byte("hello"), []byte("hello")},
+		{"CR LF", []byte("a\r\nb"), []byte("a\rb")},
+		{"CR NUL", []byte("a\r\x00b"), []byte("a\rb")},
+		{"LF alone", []byte("a\nb"), []byte("a\nb")},
+		{"NUL alone", []byte("a\x00b"), []byte("a\x00b")},
+		{"doubled IAC", []byte{'a', iac, iac, 'b'}, []byte{'a', iac, 'b'}},
+		{"CR before IAC IAC", []byte{'\r', iac, iac, '\n'}, []byte{'\r', iac, '\n'}},
+		{"interrupt", []byte{'a', iac, ip, 'b'}, []byte{'a', Interrupt, 'b'}},
+		{"NOP", []byte{'a', iac, 241, 'b'}, []byte("ab")},
+		{"empty subnegotiation", []byte{'a', iac, sb, iac, se, 'b'}, []byte("ab")},
+		{"unknown subnegotiation", []byte{'a', iac, sb, 42, 1, 2, iac, iac, 3, iac, se, 'b'}, []byte("ab")},
+		{"short NAWS", []byte{'a', iac, sb, optNAWS, 0, 80, iac, se, 'b'}, []byte("ab")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, cl := pipe(t)
+			cl.send(tt.in...)
+			if got := read(t, c, len(tt.want)); !bytes.Equal(got, tt.want) {
+				t.Errorf("Read() = %q, want %q", got, tt.want)
+			}
+			if cols, _ := c.Size(); cols != 0 {
+				t.Errorf("Size() changed to %d columns", cols)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	c, cl := pipe(t)
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Write([]byte{'a', '\n', 'b', '\r', '\n', iac, 'c'})
+		done <- err
+	}()
+	cl.expect('a', '\r', '\n', 'b', '\r', '\n', iac, iac, 'c')
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
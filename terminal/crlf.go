@@ -0,0 +1,40 @@
+package terminal
+
+import (
+	"bytes"
+	"io"
+)
+
+// crlfWriter turns bare newlines into CR LF
+type crlfWriter struct {
+	w    io.Writer
+	prev byte
+}
+
+// CRLF wraps w for terminals that don't translate newlines themselves, like
+// an SSH channel or a tty in raw mode
+func CRLF(w io.Writer) io.Writer {
+	return &crlfWriter{w: w}
+}
+
+func (c *crlfWriter) Write(p []byte) (int, error) {
+	if bytes.IndexByte(p, '\n') < 0 {
+		if len(p) > 0 {
+			c.prev = p[len(p)-1]
+		}
+		return c.w.Write(p)
+	}
+
+	out := make([]byte, 0, len(p)+bytes.Count(p, []byte{'\n'}))
+	for _, b := range p {
+		if b == '\n' && c.prev != '\r' {
+			out = append(out, '\r')
+		}
+		out = append(out, b)
+		c.prev = b
+	}
+	if _, err := c.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
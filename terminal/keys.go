@@ -0,0 +1,58 @@
+package terminal
+
+// key is a key press: a byte the user typed, or one of the arrow keys
+type key int
+
+const (
+	keyUp key = 256 + iota
+	keyDown
+	keyRight
+	keyLeft
+)
+
+// maxEscape bounds an escape sequence, longer ones are dropped
+const maxEscape = 8
+
+// keyDecoder turns the escape sequences terminals send for arrow keys into
+// single keys. Other sequences are swallowed.
+type keyDecoder struct {
+	seq []byte // escape sequence read so far
+}
+
+// decode feeds b in, returning a key once one is complete
+func (d *keyDecoder) decode(b byte) (key, bool) {
+	switch len(d.seq) {
+	case 0:
+		if b == 0x1b {
+			d.seq = append(d.seq, b)
+			return 0, false
+		}
+		return key(b), true
+	case 1:
+		// CSI, or SS3 which terminals use in application cursor mode
+		if b == '[' || b == 'O' {
+			d.seq = append(d.seq, b)
+			return 0, false
+		}
+		d.seq = d.seq[:0]
+		return key(b), true
+	}
+
+	// Parameters, as in ESC [ 1 ; 5 C, come before the final byte
+	if (b >= '0' && b <= '9' || b == ';') && len(d.seq) < maxEscape {
+		d.seq = append(d.seq, b)
+		return 0, false
+	}
+	d.seq = d.seq[:0]
+	switch b {
+	case 'A':
+		return keyUp, true
+	case 'B':
+		return keyDown, true
+	case 'C':
+		return keyRight, true
+	case 'D':
+		return keyLeft, true
+	}
+	return 0, false
+}
@@ -0,0 +1,307 @@
+// Package terminal plays animations to an interactive terminal, such as a
+// telnet or SSH client, with a menu to pick them from. The transport feeds it
+// keys and window sizes and it writes plain terminal output.
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"seal-ascii/animations"
+	"seal-ascii/render"
+)
+
+const (
+	hideCursor = "\033[?25l"
+	showCursor = "\033[?25h"
+	resetSGR   = "\033[0m"
+
+	// footer is drawn under every frame, shortFooter when it doesn't fit
+	footer      = "\n🦭 space pause · +/- speed · ←/→ animation · m menu · q quit"
+	shortFooter = "\n🦭 m menu · q quit"
+
+	// defaultAnimation is played when the menu choice is left empty
+	defaultAnimation = "seal"
+
+	// Keys understood everywhere, besides q
+	ctrlC = 0x03
+	ctrlD = 0x04
+)
+
+// Size is a terminal window in character cells
+type Size struct {
+	Cols, Rows int
+}
+
+// Session plays animations to one terminal until the user quits, goes
+// away or the server shuts down
+type Session struct {
+	Out       io.Writer
+	Keys      <-chan byte     // what the user types, closed when they go away
+	Sizes     <-chan Size     // window size changes
+	Size      Size            // window size at the start, 0 where unknown
+	Term      string          // terminal type, picks the colour mode
+	ColorTerm string          // COLORTERM, when the transport knows it
+	Color     string          // colour mode as for ?color=, empty to follow the terminal
+	Echo      bool            // write back what the user types at the menu
+	Idle      time.Duration   // how long the menu waits for a choice, 0 forever
+	Closing   <-chan struct{} // closed when the server is shutting down
+	Goodbye   string          // written when Closing is closed
+}
+
+// speeds are the playback speeds +/- step through
+var speeds = []float64{0.25, 0.5, 0.75, 1, 1.5, 2, 3, 4}
+
+// normalSpeed is the index of 1 in speeds
+const normalSpeed = 3
+
+// errQuit ends the session
+var errQuit = errors.New("quit")
+
+// Run shows the menu, or plays name straight away if it is set, until the
+// session ends. It only returns write errors and an invalid Color,
+// everything else is a normal end.
+func (s *Session) Run(name string) error {
+	if _, err := io.WriteString(s.Out, hideCursor); err != nil {
+		return err
+	}
+	defer io.WriteString(s.Out, resetSGR+showCursor+"\n")
+
+	for {
+		var err error
+		if name == "" {
+			name, err = s.menu()
+		}
+		if err == nil {
+			name, err = s.play(name)
+		}
+		switch {
+		case err == errQuit:
+			return nil
+		case err != nil:
+			return err
+		}
+	}
+}
+
+// neighbour returns the animation step places from name in the menu order,
+// wrapping around
+func neighbour(name string, step int) string {
+	names := animations.Names()
+	for i, n := range names {
+		if n == name {
+			return names[(i+step+len(names))%len(names)]
+		}
+	}
+	return names[0]
+}
+
+// write writes p, turning a failed write into the error ending the session
+func (s *Session) write(p string) error {
+	_, err := io.WriteString(s.Out, p)
+	return err
+}
+
+// quits reports whether key ends the session
+func quits(key byte) bool {
+	return key == 'q' || key == 'Q' || key == ctrlC || key == ctrlD
+}
+
+// menu lists the animations and reads a choice. An empty choice picks the
+// seal.
+func (s *Session) menu() (string, error) {
+	names := animations.Names()
+	fallback := names[0]
+	if _, ok := animations.FrameMap[defaultAnimation]; ok {
+		fallback = defaultAnimation
+	}
+
+	var b strings.Builder
+	b.WriteString(render.ClearScreen + "🦭 Seal ASCII Animations\n\n")
+	for i, name := range names {
+		fmt.Fprintf(&b, "  %d) %s\n", i+1, name)
+	}
+	fmt.Fprintf(&b, "\nPick a number and press Enter (Enter plays %s, q quits): ", fallback)
+	if err := s.write(b.String() + showCursor); err != nil {
+		return "", err
+	}
+	defer s.write(hideCursor)
+
+	var idle <-chan time.Time
+	if s.Idle > 0 {
+		t := time.NewTimer(s.Idle)
+		defer t.Stop()
+		idle = t.C
+	}
+	input := ""
+	for {
+		select {
+		case key, ok := <-s.Keys:
+			if !ok || quits(key) {
+				return "", errQuit
+			}
+			var err error
+			switch {
+			case key >= '0' && key <= '9' && len(input) < 4:
+				input += string(key)
+				if s.Echo {
+					err = s.write(string(key))
+				}
+			case (key == 0x7f || key == '\b') && input != "":
+				input = input[:len(input)-1]
+				if s.Echo {
+					err = s.write("\b \b")
+				}
+			case key == '\r' || key == '\n':
+				if input == "" {
+					return fallback, nil
+				}
+				if n, _ := strconv.Atoi(input); n >= 1 && n <= len(names) {
+					return names[n-1], nil
+				}
+				err = s.write("\nThere's no animation " + input + ", try again: ")
+				input = ""
+			}
+			if err != nil {
+				return "", err
+			}
+
+		case size := <-s.Sizes:
+			s.Size = size
+
+		case <-idle:
+			s.write("\n\nNo choice for " + s.Idle.String() + ", bye!\n")
+			return "", errQuit
+
+		case <-s.Closing:
+			s.write(s.Goodbye)
+			return "", errQuit
+		}
+	}
+}
+
+// play streams name sized to the window, redrawing when it changes, until
+// the user picks another animation, asks for the menu or quits. It returns
+// the animation to play next, empty for the menu. A finite playback shows
+// its outro and waits for a key.
+func (s *Session) play(name string) (string, error) {
+	animation := animations.FrameMap[name]
+	colorMode, err := render.ParseColor(s.Color, s.ColorTerm, s.Term)
+	if err != nil {
+		return "", err
+	}
+
+	// Fit above the footer and the cursor line
+	var fitted *animations.FrameType
+	var stream *render.Stream
+	fit := func() {
+		rows := s.Size.Rows
+		if rows > 0 {
+			rows = max(rows-2, 1)
+		}
+		fitted = animation.Fit(s.Size.Cols, rows)
+		help := footer
+		if s.Size.Cols > 0 && s.Size.Cols < utf8.RuneCountInString(footer) {
+			help = shortFooter
+		}
+		stream = render.Shared(fitted, help, colorMode).Stream(true)
+	}
+	fit()
+
+	// Speed scales the frame durations rather than deriving a variant, so
+	// stepping through speeds costs nothing
+	speed := normalSpeed
+	sleep := func(index int) time.Duration {
+		return max(time.Duration(float64(fitted.GetSleep(index))/speeds[speed]), animations.MinSleep)
+	}
+
+	sequence := animations.NewSequence(animation.GetLength(), animation.GetPlayback())
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	var keys keyDecoder
+	index, paused := 0, false
+	for {
+		select {
+		case <-timer.C:
+			next, ok := sequence.Next()
+			if !ok {
+				return s.outro(animation.GetPlayback().Outro)
+			}
+			index = next
+			if err := s.write(string(stream.Render(index))); err != nil {
+				return "", err
+			}
+			timer.Reset(sleep(index))
+
+		case b, ok := <-s.Keys:
+			if !ok {
+				return "", errQuit
+			}
+			k, ok := keys.decode(b)
+			if !ok {
+				continue
+			}
+			switch {
+			case k < 256 && quits(byte(k)):
+				return "", errQuit
+			case k == 'm' || k == 'M':
+				return "", nil
+			case k == 'n' || k == 'N' || k == keyRight:
+				return neighbour(name, 1), nil
+			case k == 'p' || k == 'P' || k == keyLeft:
+				return neighbour(name, -1), nil
+			case k == ' ':
+				paused = !paused
+				timer.Stop()
+				if !paused {
+					timer.Reset(sleep(index))
+				}
+			case k == '+' || k == '=' || k == keyUp:
+				speed = min(speed+1, len(speeds)-1)
+			case k == '-' || k == '_' || k == keyDown:
+				speed = max(speed-1, 0)
+			}
+
+		case size := <-s.Sizes:
+			s.Size = size
+			fit()
+			if err := s.write(string(stream.Render(index))); err != nil {
+				return "", err
+			}
+
+		case <-s.Closing:
+			s.write(s.Goodbye)
+			return "", errQuit
+		}
+	}
+}
+
+// outro shows the message a finite playback ends with and goes back to the
+// menu on the next key
+func (s *Session) outro(message string) (string, error) {
+	if message != "" {
+		message = "\n" + message
+	}
+	if err := s.write(message + "\nPress any key for the menu, q quits"); err != nil {
+		return "", err
+	}
+	for {
+		select {
+		case key, ok := <-s.Keys:
+			if !ok || quits(key) {
+				return "", errQuit
+			}
+			return "", nil
+		case size := <-s.Sizes:
+			s.Size = size
+		case <-s.Closing:
+			s.write(s.Goodbye)
+			return "", errQuit
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"seal-ascii/animations"
+	"seal-ascii/convert"
+	"seal-ascii/render"
+)
+
+// List is the body of /list
+type List struct {
+	Animations []string            `json:"animations"`
+	Charsets   map[string][]string `json:"charsets"`
+	Usage      string              `json:"usage"`
+	Example    string              `json:"example"`
+}
+
+// Error is the body of every JSON API error
+type Error struct {
+	Error string `json:"error"`
+}
+
+// ServeList writes the registered animations and the charsets each offers,
+// with usage and example commands for the entry point
+func ServeList(w http.ResponseWriter, usage, example string) {
+	list := List{
+		Animations: animations.Names(),
+		Charsets:   make(map[string][]string, len(animations.FrameMap)),
+		Usage:      usage,
+		Example:    example,
+	}
+	for _, info := range animations.DescribeAll().Animations {
+		list.Charsets[info.Name] = info.Charsets
+	}
+	WriteJSON(w, http.StatusOK, list)
+}
+
+// WriteJSON writes v as an indented JSON response
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// WriteError writes a JSON error response
+func WriteError(w http.ResponseWriter, status int, message string) {
+	WriteJSON(w, status, Error{Error: message})
+}
+
+// Variant sizes, retimes and recodes animation as asked for in query
+func Variant(animation *animations.FrameType, query url.Values) (*animations.FrameType, error) {
+	_, retimed, charset, err := variant(animation, query, 0)
+	if err != nil {
+		return nil, err
+	}
+	return retimed.Glyphs(charset), nil
+}
+
+// variant fits animation to query's cols and rows, less reserve rows, and
+// retimes it. The fitted animation comes back too, so callers can tell
+// whether retiming made a new one, along with the charset to redraw in.
+func variant(animation *animations.FrameType, query url.Values, reserve int) (fitted, retimed *animations.FrameType, charset convert.Charset, err error) {
+	cols, rows, err := animations.ParseSize(query.Get("cols"), query.Get("rows"))
+	if err != nil {
+		return nil, nil, charset, err
+	}
+	if rows > 0 {
+		rows = max(rows-reserve, 1)
+	}
+	// Fitted sizes are cached per bucket
+	fitted = animation.Fit(cols, rows)
+	retimed, err = fitted.Retime(query.Get("fps"), query.Get("speed"))
+	if err != nil {
+		return nil, nil, charset, err
+	}
+	// Charsets are applied last since sizing and retiming work on the
+	// braille dots
+	charset, err = convert.ParseCharset(query.Get("charset"))
+	return fitted, retimed, charset, err
+}
+
+// Resolved is a streamed animation with a client's options applied
+type Resolved struct {
+	Animation *animations.FrameType // the variant played, timing the frames
+	Frames    *render.Stream        // the variant's encoded frames
+	Playback  animations.Playback
+	Color     render.ColorMode
+}
+
+// Resolve applies the options in query to animation for a terminal stream:
+// size, timing, charset, colour, playback and delta rendering. Reserve rows
+// are left free under the frames, for the cursor line and any footer, and
+// footer is drawn under every frame. Colour falls back on the client's
+// COLORTERM and TERM headers.
+func Resolve(animation *animations.FrameType, query url.Values, header http.Header, footer string, reserve int) (Resolved, error) {
+	fitted, retimed, charset, err := variant(animation, query, reserve)
+	if err != nil {
+		return Resolved{}, err
+	}
+	mode, err := render.ParseColor(query.Get("color"), header.Get("COLORTERM"), header.Get("TERM"))
+	if err != nil {
+		return Resolved{}, err
+	}
+	playback, err := retimed.GetPlayback().Override(query.Get("mode"), query.Get("loops"))
+	if err != nil {
+		return Resolved{}, err
+	}
+	delta, err := render.ParseDelta(query.Get("delta"))
+	if err != nil {
+		return Resolved{}, err
+	}
+
+	// Registered animations share one encoded cache across all viewers,
+	// retimed variants get their own
+	cache := render.Shared(fitted.Glyphs(charset), footer, mode)
+	if retimed != fitted {
+		cache = render.NewCache(retimed.Glyphs(charset), footer, mode)
+	}
+	return Resolved{
+		Animation: retimed.Glyphs(charset),
+		Frames:    cache.Stream(delta),
+		Playback:  playback,
+		Color:     mode,
+	}, nil
+}
@@ -0,0 +1,49 @@
+package web
+
+import (
+	"bufio"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+
+	"seal-ascii/asciicast"
+	"seal-ascii/render"
+)
+
+// MaxCastLoops caps ?loops= for recordings, which hold every pass in full
+const MaxCastLoops = 10
+
+// ServeCast writes the animation registered as name as an asciicast v2
+// recording for asciinema-player and asciinema play. The query takes the
+// frames endpoint's parameters and picks the colour mode, truecolor unless ?color= says otherwise, and the playback
+// through ?mode= and ?loops=, at most MaxCastLoops passes.
+func ServeCast(w http.ResponseWriter, name string, query url.Values) {
+	animation, ok := lookup(w, name, query)
+	if !ok {
+		return
+	}
+	mode, err := render.ParseColor(query.Get("color"), "truecolor", "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	playback, err := animation.GetPlayback().Override(query.Get("mode"), query.Get("loops"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if playback.Loops > MaxCastLoops {
+		http.Error(w, fmt.Sprintf("loop count %d is more than the %d a recording can hold", playback.Loops, MaxCastLoops), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", asciicast.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": name + ".cast"}))
+	// Once the header is out a failed write only means the client went away
+	bw := bufio.NewWriter(w)
+	opts := asciicast.Options{Title: name, Color: mode, Playback: playback}
+	if asciicast.Encode(bw, animation, opts) == nil {
+		bw.Flush()
+	}
+}
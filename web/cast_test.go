@@ -0,0 +1,49 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"seal-ascii/animations"
+	"seal-ascii/asciicast"
+)
+
+func TestServeCastLoops(t *testing.T) {
+	animations.FrameMap["dots"] = animations.NewFrameType([]string{"⠁\n", "⠂\n"}, 10*time.Millisecond)
+	t.Cleanup(func() { delete(animations.FrameMap, "dots") })
+
+	tests := []struct {
+		loops  string
+		status int
+		frames int
+	}{
+		{"", http.StatusOK, 2},
+		{"3", http.StatusOK, 6},
+		{"10", http.StatusOK, 20},
+		{"11", http.StatusBadRequest, 0},
+		{"1000", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run("loops="+tt.loops, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ServeCast(w, "dots", url.Values{"loops": {tt.loops}})
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if ct := w.Header().Get("Content-Type"); ct != asciicast.ContentType {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			// The header, hiding the cursor, the frames and the end
+			if lines := strings.Count(w.Body.String(), "\n"); lines != tt.frames+3 {
+				t.Errorf("%d lines, want %d", lines, tt.frames+3)
+			}
+		})
+	}
+}
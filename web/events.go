@@ -0,0 +1,143 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"seal-ascii/animations"
+)
+
+// frameEvent is the data of each "frame" event
+type frameEvent struct {
+	Index      int    `json:"index"`       // frame index within the animation
+	TimeMS     int64  `json:"time_ms"`     // when the frame shows, from the start of playback
+	DurationMS int64  `json:"duration_ms"` // how long the frame stays up
+	Text       string `json:"text"`
+}
+
+// Events streams an animation as Server-Sent Events. Every frame is a
+// "frame" event whose id counts the events sent, so a client reconnecting
+// with Last-Event-ID picks up at the frame after the last one it saw. A
+// finite playback ends with an "end" event carrying the outro, and a
+// shutting down server sends "goodbye".
+type Events struct {
+	Animation    *animations.FrameType
+	Playback     animations.Playback
+	MaxDuration  time.Duration   // cut the stream after this long, 0 for no limit
+	WriteTimeout time.Duration   // how long one event write may block
+	Closing      <-chan struct{} // closed when the server is shutting down
+}
+
+const (
+	// retryMS is the reconnect delay suggested to clients
+	retryMS = 1000
+
+	// maxResume caps Last-Event-ID, skipping ahead costs a step per event
+	maxResume = 1 << 20
+)
+
+func (e Events) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Resume after the last event the client saw, EventSource sends it as
+	// a header and polyfills often as a query parameter
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("lastEventId")
+	}
+	resume, err := strconv.Atoi(lastID)
+	if err != nil || resume < 0 {
+		resume = 0
+	}
+	resume = min(resume, maxResume)
+
+	ctx := r.Context()
+	write := writer(w, e.WriteTimeout)
+
+	// Skip the frames the client already has, keeping the clock in step. A
+	// client that already saw the end gets 204, which stops EventSource
+	// from reconnecting.
+	sequence := animations.NewSequence(e.Animation.GetLength(), e.Playback)
+	var elapsed time.Duration
+	id := 0
+	for ; id < resume; id++ {
+		index, ok := sequence.Next()
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		elapsed += e.Animation.GetSleep(index)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // stop nginx buffering the stream
+
+	if err := write([]byte("retry: " + strconv.Itoa(retryMS) + "\n\n")); err != nil {
+		return
+	}
+
+	var deadline <-chan time.Time
+	if e.MaxDuration > 0 {
+		t := time.NewTimer(e.MaxDuration)
+		defer t.Stop()
+		deadline = t.C
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	var buf bytes.Buffer
+	for {
+		select {
+		case <-timer.C:
+			index, ok := sequence.Next()
+			if !ok {
+				write(event(&buf, strconv.Itoa(id+1), "end", e.Playback.Outro))
+				return
+			}
+			id++
+			sleep := e.Animation.GetSleep(index)
+			data, _ := json.Marshal(frameEvent{
+				Index:      index,
+				TimeMS:     elapsed.Milliseconds(),
+				DurationMS: sleep.Milliseconds(),
+				Text:       e.Animation.GetFrame(index),
+			})
+			if err := write(event(&buf, strconv.Itoa(id), "frame", string(data))); err != nil {
+				return
+			}
+			elapsed += sleep
+			timer.Reset(sleep)
+
+		case <-deadline:
+			// The client reconnects and resumes from the last id
+			return
+
+		case <-e.Closing:
+			write(event(&buf, "", "goodbye", "server shutting down"))
+			return
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// event formats one SSE event into buf and returns its bytes, which stay
+// valid until the next call
+func event(buf *bytes.Buffer, id, name, data string) []byte {
+	buf.Reset()
+	if id != "" {
+		fmt.Fprintf(buf, "id: %s\n", id)
+	}
+	fmt.Fprintf(buf, "event: %s\n", name)
+	for _, line := range bytes.Split([]byte(data), []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
@@ -0,0 +1,121 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"seal-ascii/animations"
+	"seal-ascii/render"
+)
+
+// bufferedLoops is how many passes of an endless playback a writer that
+// can't flush gets in one go
+const bufferedLoops = 3
+
+// Stream plays an animation as terminal output, each frame staying up for
+// its own duration. It ends with the outro when a finite playback is over,
+// with Timeout once MaxDuration has passed and with Goodbye when the server
+// shuts down, and as soon as the client goes away or a write fails.
+type Stream struct {
+	Animation    *animations.FrameType // timing of the frames
+	Frames       *render.Stream        // the viewer's output of the same frames
+	Playback     animations.Playback
+	MaxDuration  time.Duration   // cut the stream after this long, 0 for no limit
+	Timeout      string          // written when MaxDuration cuts the stream
+	WriteTimeout time.Duration   // how long one frame write may block
+	Closing      <-chan struct{} // closed when the server is shutting down
+	Goodbye      string          // written when Closing is closed
+}
+
+func (s Stream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if _, ok := w.(http.Flusher); !ok {
+		s.serveBuffered(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	write := writer(w, s.WriteTimeout)
+	sequence := animations.NewSequence(s.Animation.GetLength(), s.Playback)
+	var deadline <-chan time.Time
+	if s.MaxDuration > 0 {
+		t := time.NewTimer(s.MaxDuration)
+		defer t.Stop()
+		deadline = t.C
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			index, ok := sequence.Next()
+			if !ok {
+				if s.Playback.Outro != "" {
+					write([]byte(s.Playback.Outro + "\n"))
+				}
+				return
+			}
+
+			// Draw the frame from the cache, only changed cells after the first
+			if err := write(s.Frames.Render(index)); err != nil {
+				return
+			}
+			timer.Reset(s.Animation.GetSleep(index))
+
+		case <-deadline:
+			write([]byte(s.Timeout))
+			return
+
+		case <-s.Closing:
+			write([]byte(s.Goodbye))
+			return
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// serveBuffered plays a few passes into a writer that can't flush, which
+// the client sees all at once
+func (s Stream) serveBuffered(w http.ResponseWriter, r *http.Request) {
+	playback := s.Playback
+	if !playback.Finite() {
+		playback.Loops = bufferedLoops
+	}
+	sequence := animations.NewSequence(s.Animation.GetLength(), playback)
+	for index, ok := sequence.Next(); ok; index, ok = sequence.Next() {
+		if _, err := w.Write(s.Frames.Render(index)); err != nil {
+			return
+		}
+		select {
+		case <-time.After(s.Animation.GetSleep(index)):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if playback.Outro != "" {
+		w.Write([]byte(playback.Outro + "\n"))
+	}
+}
+
+// writer returns a function that writes and flushes p, giving up if the
+// client doesn't take it within timeout, 0 for no limit
+func writer(w http.ResponseWriter, timeout time.Duration) func(p []byte) error {
+	rc := http.NewResponseController(w)
+	return func(p []byte) error {
+		if timeout > 0 {
+			if err := rc.SetWriteDeadline(time.Now().Add(timeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+		}
+		if _, err := w.Write(p); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+}
@@ -0,0 +1,140 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"seal-ascii/animations"
+	"seal-ascii/render"
+)
+
+// testStream plays four frames of 10ms each
+func testStream(playback animations.Playback) Stream {
+	animation := animations.NewFrameType([]string{"a\n", "b\n", "c\n", "d\n"}, 10*time.Millisecond).WithPlayback(playback)
+	return Stream{
+		Animation: animation,
+		Frames:    render.NewCache(animation, "", render.NoColor).Stream(false),
+		Playback:  playback,
+	}
+}
+
+// serve runs s in the background, returning a channel closed when it returns
+func serve(s Stream, w http.ResponseWriter, r *http.Request) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.ServeHTTP(w, r)
+	}()
+	return done
+}
+
+// wait fails the test unless done is closed within a second
+func wait(t *testing.T, done <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("stream kept going after %s", what)
+	}
+}
+
+// failingWriter accepts limit writes and fails every one after
+type failingWriter struct {
+	header http.Header
+	limit  int
+	writes int
+}
+
+func (f *failingWriter) Header() http.Header { return f.header }
+func (f *failingWriter) WriteHeader(int)     {}
+func (f *failingWriter) Flush()              {}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	f.writes++
+	if f.writes > f.limit {
+		return 0, errors.New("connection reset")
+	}
+	return len(p), nil
+}
+
+// bufferedWriter can't flush
+type bufferedWriter struct {
+	strings.Builder
+	header http.Header
+}
+
+func (b *bufferedWriter) Header() http.Header { return b.header }
+func (b *bufferedWriter) WriteHeader(int)     {}
+
+func TestStreamEndsWhenClientGoesAway(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest("GET", "/seal", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	done := serve(testStream(animations.Playback{}), w, r)
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	wait(t, done, "the request was canceled")
+	if w.Body.Len() == 0 {
+		t.Error("no frames were written before the cancel")
+	}
+}
+
+func TestStreamEndsOnWriteError(t *testing.T) {
+	w := &failingWriter{header: http.Header{}, limit: 3}
+	done := serve(testStream(animations.Playback{}), w, httptest.NewRequest("GET", "/seal", nil))
+	wait(t, done, "a write failed")
+	if w.writes != 4 {
+		t.Errorf("stream made %d writes, want 3 and the failing one", w.writes)
+	}
+}
+
+func TestStreamEndsWithOutro(t *testing.T) {
+	w := httptest.NewRecorder()
+	done := serve(testStream(animations.Playback{Loops: 2, Outro: "bye"}), w, httptest.NewRequest("GET", "/seal", nil))
+	wait(t, done, "the last loop")
+	body := w.Body.String()
+	if n := strings.Count(body, render.ClearScreen); n != 8 {
+		t.Errorf("drew %d frames, want 8", n)
+	}
+	if !strings.HasSuffix(body, "bye\n") {
+		t.Errorf("body ends %q, want the outro", body[max(len(body)-20, 0):])
+	}
+}
+
+func TestStreamLimits(t *testing.T) {
+	t.Run("max duration", func(t *testing.T) {
+		s := testStream(animations.Playback{})
+		s.MaxDuration, s.Timeout = 30*time.Millisecond, "time's up"
+		w := httptest.NewRecorder()
+		wait(t, serve(s, w, httptest.NewRequest("GET", "/seal", nil)), "MaxDuration")
+		if !strings.HasSuffix(w.Body.String(), "time's up") {
+			t.Errorf("body doesn't end with the timeout message")
+		}
+	})
+	t.Run("closing", func(t *testing.T) {
+		closing := make(chan struct{})
+		s := testStream(animations.Playback{})
+		s.Closing, s.Goodbye = closing, "goodbye"
+		w := httptest.NewRecorder()
+		done := serve(s, w, httptest.NewRequest("GET", "/seal", nil))
+		close(closing)
+		wait(t, done, "the server started closing")
+		if !strings.HasSuffix(w.Body.String(), "goodbye") {
+			t.Errorf("body doesn't end with the goodbye")
+		}
+	})
+}
+
+func TestStreamWithoutFlusher(t *testing.T) {
+	w := &bufferedWriter{header: http.Header{}}
+	wait(t, serve(testStream(animations.Playback{}), w, httptest.NewRequest("GET", "/seal", nil)), "the buffered passes")
+	if n := strings.Count(w.String(), render.ClearScreen); n != 4*bufferedLoops {
+		t.Errorf("drew %d frames, want %d", n, 4*bufferedLoops)
+	}
+}
@@ -0,0 +1,47 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+
+	"seal-ascii/render"
+	"seal-ascii/svg"
+)
+
+// ServeSVG writes the animation registered as name as an animated SVG image
+// for pages that take images but no scripts, such as READMEs. The query
+// takes the frames endpoint's parameters. The frames' colours, which make the image a few times larger, are used when ?color=
+// asks for any colour mode, and ?mode= and ?loops= pick the playback.
+// Images are kept under svg.DefaultMaxSize by dropping frames.
+func ServeSVG(w http.ResponseWriter, name string, query url.Values) {
+	animation, ok := lookup(w, name, query)
+	if !ok {
+		return
+	}
+	mode, err := render.ParseColor(query.Get("color"), "", "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	playback, err := animation.GetPlayback().Override(query.Get("mode"), query.Get("loops"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var buf bytes.Buffer
+	opts := svg.Options{Title: name, Color: mode != render.NoColor, Playback: playback, MaxSize: svg.DefaultMaxSize}
+	if err := svg.Encode(&buf, animation, opts); errors.Is(err, svg.ErrTooLarge) {
+		http.Error(w, err.Error()+", try a smaller ?cols=", http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", svg.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": name + ".svg"}))
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,77 @@
+// Package web holds the pages served to browsers by both entry points.
+package web
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"seal-ascii/animations"
+)
+
+//go:embed player.html
+var playerHTML string
+
+// player plays an animation in the browser from its JSON frames
+var player = template.Must(template.New("player").Parse(playerHTML))
+
+// Player describes the page for one animation
+type Player struct {
+	Name      string   // animation name
+	FramesURL string   // JSON frames endpoint, may carry a query string
+	CurlURL   string   // URL to suggest for terminals
+	ListURL   string   // animation list
+	Charset   string   // glyph set selected initially
+	Charsets  []string // glyph sets the animation offers
+}
+
+// NewPlayer describes the page for animation, registered as name, whose
+// routes are mounted under base. The frames endpoint gets the page's query,
+// less the parameters only the page uses, and curlURL is suggested for
+// terminals.
+func NewPlayer(name string, animation *animations.FrameType, query url.Values, base, curlURL string) Player {
+	frames := url.Values{}
+	for key, values := range query {
+		if key != "path" && key != "format" && key != "charset" {
+			frames[key] = values
+		}
+	}
+	framesURL := base + "/api/v1/animations/" + url.PathEscape(name) + "/frames"
+	if len(frames) > 0 {
+		framesURL += "?" + frames.Encode()
+	}
+	return Player{
+		Name:      name,
+		FramesURL: framesURL,
+		CurlURL:   curlURL,
+		ListURL:   base + "/api/v1/animations",
+		Charset:   query.Get("charset"),
+		Charsets:  animation.Describe(name).Charsets,
+	}
+}
+
+// ServePlayer writes the player page for p
+func ServePlayer(w http.ResponseWriter, p Player) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := player.Execute(w, p); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// lookup finds the animation registered as name and applies the size,
+// timing and charset in query, answering the request itself if it can't
+func lookup(w http.ResponseWriter, name string, query url.Values) (*animations.FrameType, bool) {
+	animation, exists := animations.FrameMap[name]
+	if !exists {
+		http.Error(w, fmt.Sprintf("Animation '%s' not found", name), http.StatusNotFound)
+		return nil, false
+	}
+	animation, err := Variant(animation, query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return animation, true
+}
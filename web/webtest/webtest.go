@@ -0,0 +1,132 @@
+// Package webtest holds the fixtures and route checks shared by the tests of
+// the mux server and the Vercel function, which answer the same requests.
+package webtest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"seal-ascii/animations"
+	"seal-ascii/web"
+)
+
+// Register adds small, fast animations to the registry for the duration of
+// a test: "dots" with four frames and "blink" with two, 20ms each
+func Register(t *testing.T) {
+	t.Helper()
+	frames := []string{"⠁⠂\n", "⠄⡀\n", "⠈⠐\n", "⠠⢀\n"}
+	added := map[string]*animations.FrameType{
+		"dots":  animations.NewFrameType(frames, 20*time.Millisecond),
+		"blink": animations.NewFrameType(frames[:2], 20*time.Millisecond),
+	}
+	for name, animation := range added {
+		animations.FrameMap[name] = animation
+	}
+	t.Cleanup(func() {
+		for name := range added {
+			delete(animations.FrameMap, name)
+		}
+	})
+}
+
+// Route is a request path and the response it should get
+type Route struct {
+	Path   string
+	Status int
+	Want   any    // decoded JSON body, nil for text
+	Text   string // start of a text body
+}
+
+// List is the /list body expected with the entry point's usage and example
+func List(usage, example string) web.List {
+	list := web.List{
+		Animations: animations.Names(),
+		Charsets:   map[string][]string{},
+		Usage:      usage,
+		Example:    example,
+	}
+	for _, name := range list.Animations {
+		list.Charsets[name] = animations.FrameMap[name].Describe(name).Charsets
+	}
+	return list
+}
+
+// Routes are the requests both entry points answer alike, given the /list
+// body. Call it after Register.
+func Routes(t *testing.T, list web.List) []Route {
+	t.Helper()
+	names := animations.Names()
+	if !slices.IsSorted(names) {
+		t.Fatalf("Names() = %v, want them sorted", names)
+	}
+
+	// The catalog is in the same order
+	var catalog animations.Catalog
+	for _, name := range names {
+		catalog.Animations = append(catalog.Animations, animations.FrameMap[name].Describe(name))
+	}
+	dots := animations.FrameMap["dots"].Describe("dots")
+	notFound := web.Error{Error: `animation "walrus" not found`}
+
+	return []Route{
+		{"/list", http.StatusOK, list, ""},
+		{"/api/v1/animations", http.StatusOK, catalog, ""},
+		{"/api/v1/animations/dots", http.StatusOK, dots, ""},
+		{"/dots?format=json", http.StatusOK, dots, ""},
+		{"/api/v1/animations/walrus", http.StatusNotFound, notFound, ""},
+		{"/api/v1/animations/walrus/frames", http.StatusNotFound, notFound, ""},
+		{"/api/v1/animations/walrus/events", http.StatusNotFound, notFound, ""},
+		{"/api/v1/animations/dots/frames?fps=NaN", http.StatusBadRequest, web.Error{Error: `invalid fps "NaN"`}, ""},
+		{"/api/v1/nothing", http.StatusNotFound, web.Error{Error: "no such endpoint"}, ""},
+		{"/walrus", http.StatusNotFound, nil, "Animation 'walrus' not found"},
+		{"/walrus.cast", http.StatusNotFound, nil, "Animation 'walrus' not found"},
+		{"/walrus.svg", http.StatusNotFound, nil, "Animation 'walrus' not found"},
+		{"/dots.cast?loops=1000", http.StatusBadRequest, nil, "loop count 1000 is more than"},
+		{"/dots?format=text", http.StatusOK, nil, "⠁⠂\n"},
+	}
+}
+
+// CheckRoutes serves each route through h, under prefix
+func CheckRoutes(t *testing.T, h http.Handler, prefix string, routes []Route) {
+	t.Helper()
+	for _, route := range routes {
+		t.Run(route.Path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", prefix+route.Path, nil))
+			Check(t, w.Result(), route)
+		})
+	}
+}
+
+// Check compares a response with the status and body route wants
+func Check(t *testing.T, resp *http.Response, route Route) {
+	t.Helper()
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != route.Status {
+		t.Fatalf("status = %d, want %d: %s", resp.StatusCode, route.Status, body)
+	}
+	if route.Want == nil {
+		if !strings.HasPrefix(string(body), route.Text) {
+			t.Errorf("body = %q, want it to start with %q", body, route.Text)
+		}
+		return
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	got := reflect.New(reflect.TypeOf(route.Want))
+	if err := json.Unmarshal(body, got.Interface()); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+	if !reflect.DeepEqual(got.Elem().Interface(), route.Want) {
+		t.Errorf("body = %+v, want %+v", got.Elem().Interface(), route.Want)
+	}
+}